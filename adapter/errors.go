@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/minio/minio-go/v7"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// translateError classifies a backend error (gRPC status, Google API or S3
+// HTTP error, or filesystem error) as one of the models error kinds. Errors that do
+// not map to a kind are returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrObjectNotExist), errors.Is(err, storage.ErrBucketNotExist), errors.Is(err, os.ErrNotExist):
+		return models.WrapError(models.ErrNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.WrapError(models.ErrUnavailable, err)
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return translateHTTPStatus(apiErr.Code, err)
+	}
+
+	var s3Err minio.ErrorResponse
+	if errors.As(err, &s3Err) && s3Err.StatusCode != 0 {
+		return translateHTTPStatus(s3Err.StatusCode, err)
+	}
+
+	switch status.Code(err) {
+	case codes.NotFound:
+		return models.WrapError(models.ErrNotFound, err)
+	case codes.AlreadyExists:
+		return models.WrapError(models.ErrConflict, err)
+	case codes.InvalidArgument, codes.OutOfRange:
+		return models.WrapError(models.ErrValidation, err)
+	// Aborted means a transaction lost to contention after its retries;
+	// the same request may succeed when repeated.
+	case codes.Aborted, codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return models.WrapError(models.ErrUnavailable, err)
+	}
+	return err
+}
+
+// translateHTTPStatus classifies an error that carries an HTTP status code.
+func translateHTTPStatus(code int, err error) error {
+	switch code {
+	case http.StatusNotFound:
+		return models.WrapError(models.ErrNotFound, err)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return models.WrapError(models.ErrConflict, err)
+	case http.StatusBadRequest, http.StatusRequestedRangeNotSatisfiable:
+		return models.WrapError(models.ErrValidation, err)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return models.WrapError(models.ErrUnavailable, err)
+	}
+	return err
+}
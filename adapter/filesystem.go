@@ -0,0 +1,190 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// localTempPrefix marks files that are still being written.
+const localTempPrefix = ".tmp-"
+
+// LocalObjectStore is an ObjectStore that keeps objects as files below a root
+// directory, so the catalog can run on-premises or without a cloud bucket.
+// Content types are derived from file extensions, and object versions from
+// the modification time and size of the file.
+type LocalObjectStore struct {
+	root string
+}
+
+func NewLocalObjectStore(root string) (*LocalObjectStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local object store root is empty")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local object store root: %w", err)
+	}
+	return &LocalObjectStore{root: root}, nil
+}
+
+func (s *LocalObjectStore) Stat(ctx context.Context, name string) (*repository.ObjectAttrs, error) {
+	p, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err == nil && info.IsDir() {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object attributes: %w", translateError(err))
+	}
+	return localAttrs(name, info), nil
+}
+
+func (s *LocalObjectStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.OpenRange(ctx, name, "", 0, -1)
+}
+
+func (s *LocalObjectStore) OpenRange(ctx context.Context, name, version string, offset, length int64) (io.ReadCloser, error) {
+	p, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", translateError(err))
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = translateError(fs.ErrNotExist)
+	} else if err == nil && version != "" && localAttrs(name, info).Version != version {
+		err = models.NewError(models.ErrConflict, "object %q has been replaced", name)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
+
+	if length < 0 || offset+length > info.Size() {
+		length = max(info.Size()-offset, 0)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, offset, length), file}, nil
+}
+
+// List walks only the directory holding the prefix, not the whole root.
+func (s *LocalObjectStore) List(ctx context.Context, prefix string) ([]string, error) {
+	start := s.root
+	if dir := prefix[:strings.LastIndex(prefix, "/")+1]; dir != "" {
+		var err error
+		if start, err = s.path(strings.TrimSuffix(dir, "/")); err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == start {
+			return fs.SkipAll // Nothing is stored under the prefix
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), localTempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", translateError(err))
+	}
+	return names, nil
+}
+
+func (s *LocalObjectStore) Delete(ctx context.Context, name string) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete object: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *LocalObjectStore) Write(ctx context.Context, name, contentType string, r io.Reader) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+
+	// Write to a temporary file first so readers never see a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(p), localTempPrefix+filepath.Base(p))
+	if err != nil {
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+	return nil
+}
+
+// localAttrs describes the file holding an object.
+func localAttrs(name string, info fs.FileInfo) *repository.ObjectAttrs {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	version := fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+	return &repository.ObjectAttrs{
+		Name:         name,
+		ContentType:  contentType,
+		Size:         info.Size(),
+		ETag:         `"` + version + `"`,
+		LastModified: info.ModTime(),
+		Version:      version,
+	}
+}
+
+// path maps an object name to a file below the root, rejecting names that
+// would escape it.
+func (s *LocalObjectStore) path(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || clean != "/"+strings.TrimPrefix(name, "/") {
+		return "", models.NewError(models.ErrValidation, "invalid object name %q", name)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
@@ -3,37 +3,275 @@ package adapter
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FirestoreImageRepository struct {
 	client     *firestore.Client
 	collection *firestore.CollectionRef
+	trash      *firestore.CollectionRef // Soft-deleted images, kept apart so queries on the active collection need no extra filter
+	audit      *firestore.CollectionRef // Audit log written in the same transactions as the images
+	bucketName string                   // Bucket of asset paths stored as gs:// URIs
 }
 
-func NewFirestoreCollection(client *firestore.Client, collectionName string) (*FirestoreImageRepository, error) {
+func NewFirestoreCollection(client *firestore.Client, collectionName, auditCollectionName, bucketName string) (*FirestoreImageRepository, error) {
 	return &FirestoreImageRepository{
 		client:     client,
 		collection: client.Collection(collectionName),
+		trash:      client.Collection(collectionName + "_trash"),
+		audit:      client.Collection(auditCollectionName),
+		bucketName: bucketName,
 	}, nil
 }
 
+func (r *FirestoreImageRepository) Create(ctx context.Context, image *models.Image) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// Trashed images still count, otherwise restoring one could create a duplicate.
+		for _, collection := range []*firestore.CollectionRef{r.collection, r.trash} {
+			existing, err := tx.Documents(collection.Where("file_uid", "==", image.FileUID).Limit(1)).GetAll()
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				return status.Errorf(codes.AlreadyExists, "image with file_uid %q already exists", image.FileUID)
+			}
+		}
+
+		doc := r.collection.NewDoc()
+		image.ID = doc.ID
+		return tx.Create(doc, image)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create image: %w", translateError(err))
+	}
+	return nil
+}
+
 func (r *FirestoreImageRepository) Read(ctx context.Context, imageID string) (*models.Image, error) {
 	doc, err := r.collection.Doc(imageID).Get(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read image: %w", err)
+		return nil, fmt.Errorf("failed to read image: %w", translateError(err))
 	}
-	var image models.Image
-	if err := doc.DataTo(&image); err != nil {
-		return nil, fmt.Errorf("failed to convert document to image: %w", err)
+	return imageFromDoc(doc)
+}
+
+func (r *FirestoreImageRepository) Update(ctx context.Context, image *models.Image, version int64, entries []*models.AuditEntry) error {
+	doc := r.collection.Doc(image.ID)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		if stored := storedVersion(snapshot); stored != version {
+			return models.NewError(models.ErrPrecondition, "image %q is at version %d, not %d", image.ID, stored, version)
+		}
+		updates := append(labelUpdates(image, version+1),
+			firestore.Update{Path: "dataset_name", Value: image.DatasetName},
+			firestore.Update{Path: "organ_type", Value: image.OrganType},
+		)
+		if err := tx.Update(doc, updates); err != nil {
+			return err
+		}
+		return r.createAuditEntries(tx, entries)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update image: %w", translateError(err))
 	}
-	return &image, nil
+	image.Version = version + 1
+	return nil
+}
+
+func (r *FirestoreImageRepository) ReadMany(ctx context.Context, imageIDs []string) ([]*models.Image, error) {
+	var images []*models.Image
+	for start := 0; start < len(imageIDs); start += models.MaxBatchWrites {
+		end := min(start+models.MaxBatchWrites, len(imageIDs))
+		refs := make([]*firestore.DocumentRef, 0, end-start)
+		for _, id := range imageIDs[start:end] {
+			refs = append(refs, r.collection.Doc(id))
+		}
+		snapshots, err := r.client.GetAll(ctx, refs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read images: %w", translateError(err))
+		}
+		for _, snapshot := range snapshots {
+			if !snapshot.Exists() {
+				continue
+			}
+			image, err := imageFromDoc(snapshot)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// ListByField runs one "in" query per 30 values, the most Firestore accepts.
+func (r *FirestoreImageRepository) ListByField(ctx context.Context, field string, values []string) ([]*models.Image, error) {
+	var images []*models.Image
+	for start := 0; start < len(values); start += 30 {
+		chunk := values[start:min(start+30, len(values))]
+		docs, err := r.collection.Where(field, "in", chunk).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images by %s: %w", field, translateError(err))
+		}
+		for _, doc := range docs {
+			image, err := imageFromDoc(doc)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// maxBatchOperations is the most writes Firestore accepts in one commit.
+const maxBatchOperations = 500
+
+// maxBatchAttempts bounds how often UpdateBatch commits a chunk after images
+// in it changed between the read and the commit.
+const maxBatchAttempts = 3
+
+// UpdateBatch writes the images in chunks of up to maxBatchOperations
+// writes, counting each image and its audit entries. A chunk is committed as
+// one batched write, in which each image is only updated if it has not
+// changed since it was read, so that its audit entries are stored if and
+// only if the image is. BulkWriter is not used because its writes are not
+// atomic. If an image changes before the commit the whole chunk is rejected;
+// it is then read again and committed without the changed images.
+func (r *FirestoreImageRepository) UpdateBatch(ctx context.Context, images []*models.Image, entries []*models.AuditEntry) (map[string]error, error) {
+	if len(images) > models.MaxBatchWrites {
+		return nil, models.NewError(models.ErrValidation, "at most %d images can be written in one batch", models.MaxBatchWrites)
+	}
+	imageEntries := make(map[string][]*models.AuditEntry)
+	for _, entry := range entries {
+		imageEntries[entry.ImageID] = append(imageEntries[entry.ImageID], entry)
+	}
+
+	skipped := make(map[string]error)
+	var chunk []*models.Image
+	operations := 0
+	for _, image := range images {
+		n := 1 + len(imageEntries[image.ID])
+		if len(chunk) > 0 && operations+n > maxBatchOperations {
+			r.commitLabels(ctx, chunk, imageEntries, skipped)
+			chunk, operations = nil, 0
+		}
+		chunk = append(chunk, image)
+		operations += n
+	}
+	if len(chunk) > 0 {
+		r.commitLabels(ctx, chunk, imageEntries, skipped)
+	}
+	return skipped, nil
 }
 
-func (r *FirestoreImageRepository) Update(ctx context.Context, image *models.Image) error {
-	updates := make([]firestore.Update, 0)
+// commitLabels writes a chunk of UpdateBatch, adding the images that were
+// not written to skipped.
+func (r *FirestoreImageRepository) commitLabels(ctx context.Context, images []*models.Image, imageEntries map[string][]*models.AuditEntry, skipped map[string]error) {
+	for attempt := 1; ; attempt++ {
+		refs := make([]*firestore.DocumentRef, len(images))
+		for i, image := range images {
+			refs[i] = r.collection.Doc(image.ID)
+		}
+		snapshots, err := r.client.GetAll(ctx, refs)
+		if err != nil {
+			for _, image := range images {
+				skipped[image.ID] = fmt.Errorf("failed to read image: %w", translateError(err))
+			}
+			return
+		}
+
+		batch := r.client.Batch()
+		var pending []*models.Image
+		for i, image := range images {
+			snapshot := snapshots[i]
+			if !snapshot.Exists() {
+				skipped[image.ID] = models.NewError(models.ErrNotFound, "image %q not found", image.ID)
+				continue
+			}
+			if stored := storedVersion(snapshot); stored != image.Version {
+				skipped[image.ID] = models.NewError(models.ErrPrecondition, "image %q is at version %d, not %d", image.ID, stored, image.Version)
+				continue
+			}
+			batch.Update(refs[i], labelUpdates(image, image.Version+1), firestore.LastUpdateTime(snapshot.UpdateTime))
+			for _, entry := range imageEntries[image.ID] {
+				// IDs are kept when the chunk is committed again.
+				if entry.ID == "" {
+					entry.ID = r.audit.NewDoc().ID
+				}
+				batch.Create(r.audit.Doc(entry.ID), entry)
+			}
+			pending = append(pending, image)
+		}
+		if len(pending) == 0 {
+			return
+		}
+
+		_, err = batch.Commit(ctx)
+		switch {
+		case err == nil:
+			for _, image := range pending {
+				image.Version++
+			}
+			return
+		case status.Code(err) == codes.FailedPrecondition && attempt < maxBatchAttempts:
+			// An image changed after it was read; find it by its version.
+			images = pending
+		case status.Code(err) == codes.FailedPrecondition:
+			for _, image := range pending {
+				skipped[image.ID] = models.NewError(models.ErrPrecondition, "image %q changed while the batch was written", image.ID)
+			}
+			return
+		default:
+			for _, image := range pending {
+				skipped[image.ID] = fmt.Errorf("failed to update image: %w", translateError(err))
+			}
+			return
+		}
+	}
+}
+
+// createAuditEntries adds the entries to the audit log in the transaction.
+// IDs are assigned on the first attempt and kept when the transaction is
+// retried.
+func (r *FirestoreImageRepository) createAuditEntries(tx *firestore.Transaction, entries []*models.AuditEntry) error {
+	for _, entry := range entries {
+		if entry.ID == "" {
+			entry.ID = r.audit.NewDoc().ID
+		}
+		if err := tx.Create(r.audit.Doc(entry.ID), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedVersion returns the version of an image document. Records written
+// before versioning have no version field, which reads as 0.
+func storedVersion(snapshot *firestore.DocumentSnapshot) int64 {
+	version, _ := snapshot.DataAt("version")
+	n, _ := version.(int64)
+	return n
+}
+
+// labelUpdates returns the writes that store the label fields of an image
+// together with its new version.
+func labelUpdates(image *models.Image, version int64) []firestore.Update {
+	updates := []firestore.Update{
+		{Path: "updated_at", Value: image.UpdatedAt},
+		{Path: "version", Value: version},
+	}
 
 	if image.DiseaseType != nil {
 		updates = append(updates, firestore.Update{
@@ -51,8 +289,11 @@ func (r *FirestoreImageRepository) Update(ctx context.Context, image *models.Ima
 
 	if image.SubType != nil {
 		updates = append(updates, firestore.Update{
-			Path:  "subtype",
+			Path:  "sub_type",
 			Value: image.SubType,
+		}, firestore.Update{
+			Path:  legacySubTypeField,
+			Value: firestore.Delete,
 		})
 	}
 
@@ -62,27 +303,297 @@ func (r *FirestoreImageRepository) Update(ctx context.Context, image *models.Ima
 			Value: image.Grade,
 		})
 	}
+	return updates
+}
 
-	if len(updates) == 0 {
-		return nil // No updates to apply
+func (r *FirestoreImageRepository) Delete(ctx context.Context, imageID string, entries []*models.AuditEntry) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Delete(r.collection.Doc(imageID)); err != nil {
+			return err
+		}
+		if err := tx.Delete(r.trash.Doc(imageID)); err != nil {
+			return err
+		}
+		return r.createAuditEntries(tx, entries)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete image: %w", translateError(err))
 	}
-	_, err := r.collection.Doc(image.ID).Update(ctx, updates)
+	return nil
+}
+
+func (r *FirestoreImageRepository) SoftDelete(ctx context.Context, imageID, deletedBy string, deletedAt time.Time, entries []*models.AuditEntry) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		src := r.collection.Doc(imageID)
+		doc, err := tx.Get(src)
+		if err != nil {
+			return err
+		}
+
+		// Copy the raw document so fields unknown to models.Image survive the round trip.
+		data := doc.Data()
+		data["deleted_at"] = deletedAt
+		data["deleted_by"] = deletedBy
+		if err := tx.Set(r.trash.Doc(imageID), data); err != nil {
+			return err
+		}
+		if err := tx.Delete(src); err != nil {
+			return err
+		}
+		return r.createAuditEntries(tx, entries)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update image: %w", err)
+		return fmt.Errorf("failed to soft delete image: %w", translateError(err))
 	}
 	return nil
 }
 
-func (r *FirestoreImageRepository) Delete(ctx context.Context, imageID string) error {
-	_, err := r.collection.Doc(imageID).Delete(ctx)
+func (r *FirestoreImageRepository) Restore(ctx context.Context, imageID string, entries []*models.AuditEntry) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		src := r.trash.Doc(imageID)
+		doc, err := tx.Get(src)
+		if err != nil {
+			return err
+		}
+
+		data := doc.Data()
+		delete(data, "deleted_at")
+		delete(data, "deleted_by")
+		if err := tx.Create(r.collection.Doc(imageID), data); err != nil {
+			return err
+		}
+		if err := tx.Delete(src); err != nil {
+			return err
+		}
+		datasetName, _ := data["dataset_name"].(string)
+		for _, entry := range entries {
+			entry.DatasetName = datasetName
+		}
+		return r.createAuditEntries(tx, entries)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete image: %w", err)
+		return fmt.Errorf("failed to restore image: %w", translateError(err))
 	}
 	return nil
 }
 
-func (r *FirestoreImageRepository) Filter(ctx context.Context, filter *models.ImageFilter) ([]*models.Image, error) {
+func (r *FirestoreImageRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]*models.Image, error) {
+	docs, err := r.trash.Where("deleted_at", "<", cutoff).OrderBy("deleted_at", firestore.Asc).Limit(limit).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted images: %w", translateError(err))
+	}
+
+	images := make([]*models.Image, 0, len(docs))
+	for _, doc := range docs {
+		image, err := imageFromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (r *FirestoreImageRepository) Filter(ctx context.Context, filter *models.ImageFilter) (*models.ImageList, error) {
+	queries := r.filterQueries(filter)
+
+	total, err := r.count(ctx, queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count images: %w", translateError(err))
+	}
+
+	var after []any
+	if filter.PageToken != "" {
+		cursor, err := models.DecodePageCursor(filter.PageToken, filter.SortBy, filter.Order)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := cursor.SortValue()
+		after = []any{value, cursor.ID}
+	}
+
+	// Fetch one extra document to find out whether another page exists. With
+	// several dataset chunks, each chunk's first page is fetched and merged.
+	images := []*models.Image{}
+	for _, query := range queries {
+		query = sortQuery(query, filter)
+		if after != nil {
+			query = query.StartAfter(after...)
+		}
+		docs, err := query.Limit(filter.PageSize() + 1).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter images: %w", translateError(err))
+		}
+		for _, doc := range docs {
+			image, err := imageFromDoc(doc)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, image)
+		}
+	}
+	if len(queries) > 1 {
+		slices.SortFunc(images, func(a, b *models.Image) int { return orderImages(a, b, filter) })
+	}
+
+	list := &models.ImageList{TotalCount: total}
+	if len(images) > filter.PageSize() {
+		images = images[:filter.PageSize()]
+		list.NextPageToken = models.NewPageCursor(images[len(images)-1], filter.SortBy, filter.Order).Encode()
+	}
+	list.Images = images
+	return list, nil
+}
+
+// Count runs COUNT aggregations, which Firestore answers from its indexes.
+func (r *FirestoreImageRepository) Count(ctx context.Context, filter *models.ImageFilter) (int64, error) {
+	total, err := r.count(ctx, r.filterQueries(filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count images: %w", translateError(err))
+	}
+	return total, nil
+}
+
+// Iterate streams the query results, so only the documents of the
+// current response batch are held in memory. With several dataset chunks,
+// the sorted results of all chunks are merged.
+func (r *FirestoreImageRepository) Iterate(ctx context.Context, filter *models.ImageFilter, fn func(*models.Image) error) error {
+	queries := r.filterQueries(filter)
+	iters := make([]*firestore.DocumentIterator, len(queries))
+	heads := make([]*models.Image, len(queries))
+	next := func(i int) error {
+		doc, err := iters[i].Next()
+		if err == iterator.Done {
+			heads[i] = nil
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to iterate images: %w", translateError(err))
+		}
+		heads[i], err = imageFromDoc(doc)
+		return err
+	}
+	for i, query := range queries {
+		iters[i] = sortQuery(query, filter).Documents(ctx)
+		defer iters[i].Stop()
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		first := -1
+		for i, head := range heads {
+			if head != nil && (first < 0 || orderImages(head, heads[first], filter) < 0) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil
+		}
+		if err := fn(heads[first]); err != nil {
+			return err
+		}
+		if err := next(first); err != nil {
+			return err
+		}
+	}
+}
+
+// FindByObjectPath queries every spelling of the object name that
+// models.ObjectName accepts in a stored path, so records storing gs:// URIs
+// are found as well.
+func (r *FirestoreImageRepository) FindByObjectPath(ctx context.Context, objectName string) (*models.Image, error) {
+	queries := []firestore.Query{
+		r.collection.Where("dzi_gcs_path", "in", r.pathSpellings(objectName)),
+		r.collection.Where("thumbnail_gcs_path", "in", r.pathSpellings(objectName)),
+	}
+	// Tiles may be stored under any prefix of the object name.
+	spellings := r.pathSpellings(models.ObjectPrefixes(objectName)...)
+	for start := 0; start < len(spellings); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(spellings))
+		queries = append(queries, r.collection.Where("tiles_gcs_path", "in", spellings[start:end]))
+	}
+
+	for _, query := range queries {
+		docs, err := query.Limit(firestoreInLimit).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find image by object path: %w", translateError(err))
+		}
+		for _, doc := range docs {
+			image, err := imageFromDoc(doc)
+			if err != nil {
+				return nil, err
+			}
+			if image.OwnsObject(objectName) {
+				return image, nil
+			}
+		}
+	}
+	return nil, models.NewError(models.ErrNotFound, "no image owns object %q", objectName)
+}
+
+// ListOverlapping queries both collections for assets stored under any
+// spelling of the prefix, with one range query per field and spelling, and
+// for tiles prefixes that contain it.
+func (r *FirestoreImageRepository) ListOverlapping(ctx context.Context, prefix string) ([]*models.Image, error) {
+	var queries []firestore.Query
+	for _, collection := range []*firestore.CollectionRef{r.collection, r.trash} {
+		for _, field := range []string{"tiles_gcs_path", "dzi_gcs_path", "thumbnail_gcs_path"} {
+			for _, spelling := range r.pathSpellings(prefix) {
+				queries = append(queries, collection.Where(field, ">=", spelling).Where(field, "<", spelling+"\uf8ff"))
+			}
+		}
+		spellings := r.pathSpellings(models.ObjectPrefixes(prefix)...)
+		for start := 0; start < len(spellings); start += firestoreInLimit {
+			end := min(start+firestoreInLimit, len(spellings))
+			queries = append(queries, collection.Where("tiles_gcs_path", "in", spellings[start:end]))
+		}
+	}
+
+	seen := make(map[string]bool)
+	var images []*models.Image
+	for _, query := range queries {
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images overlapping prefix: %w", translateError(err))
+		}
+		for _, doc := range docs {
+			image, err := imageFromDoc(doc)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[image.ID] && image.OverlapsPrefix(prefix) {
+				seen[image.ID] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images, nil
+}
+
+// pathSpellings returns the ways a stored path may spell the object names:
+// as the name, with a leading slash, or as a gs:// URI in the bucket.
+func (r *FirestoreImageRepository) pathSpellings(names ...string) []string {
+	spellings := make([]string, 0, 3*len(names))
+	for _, name := range names {
+		spellings = append(spellings, name, "/"+name)
+		if r.bucketName != "" {
+			spellings = append(spellings, "gs://"+r.bucketName+"/"+name)
+		}
+	}
+	return spellings
+}
+
+// filterQueries applies the equality conditions of the filter to the
+// collection. Firestore limits "in" filters to 30 values, so a longer list
+// of datasets is split into one query per 30 datasets; the queries match
+// disjoint sets of images.
+func (r *FirestoreImageRepository) filterQueries(filter *models.ImageFilter) []firestore.Query {
 	query := r.collection.Query
+	if filter.Deleted {
+		query = r.trash.Query
+	}
 
 	if filter.DatasetName != nil && *filter.DatasetName != "" {
 		query = query.Where("dataset_name", "==", *filter.DatasetName)
@@ -97,26 +608,108 @@ func (r *FirestoreImageRepository) Filter(ctx context.Context, filter *models.Im
 		query = query.Where("classification", "==", *filter.Classification)
 	}
 	if filter.SubType != nil && *filter.SubType != "" {
-		query = query.Where("subtype", "==", *filter.SubType)
+		query = query.Where("sub_type", "==", *filter.SubType)
 	}
 	if filter.Grade != nil && *filter.Grade != "" {
 		query = query.Where("grade", "==", *filter.Grade)
 	}
 
-	docs, err := query.Documents(ctx).GetAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to filter images: %w", err)
+	if len(filter.DatasetNames) == 0 {
+		return []firestore.Query{query}
 	}
+	var queries []firestore.Query
+	for start := 0; start < len(filter.DatasetNames); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(filter.DatasetNames))
+		queries = append(queries, query.Where("dataset_name", "in", filter.DatasetNames[start:end]))
+	}
+	return queries
+}
 
-	var images []*models.Image
-	for _, doc := range docs {
-		var image models.Image
-		if err := doc.DataTo(&image); err != nil {
-			return nil, fmt.Errorf("failed to convert document to image: %w", err)
+// sortQuery orders the query by the filter's sort field, breaking ties by
+// document ID so that the order is stable.
+func sortQuery(query firestore.Query, filter *models.ImageFilter) firestore.Query {
+	direction := firestore.Asc
+	if filter.Order == models.OrderDesc {
+		direction = firestore.Desc
+	}
+	return query.OrderBy(filter.SortBy, direction).OrderBy(firestore.DocumentID, direction)
+}
+
+// count runs a server-side COUNT aggregation over each query and adds up
+// the results.
+func (r *FirestoreImageRepository) count(ctx context.Context, queries []firestore.Query) (int64, error) {
+	var total int64
+	for _, query := range queries {
+		result, err := query.NewAggregationQuery().WithCount("total").Get(ctx)
+		if err != nil {
+			return 0, err
+		}
+		value, ok := result["total"].(*firestorepb.Value)
+		if !ok {
+			return 0, fmt.Errorf("unexpected count result type %T", result["total"])
 		}
-		image.ID = doc.Ref.ID // Set the ID from the document reference
-		images = append(images, &image)
+		total += value.GetIntegerValue()
 	}
+	return total, nil
+}
 
-	return images, nil
+// orderImages compares two images in the filter's sort order, the order
+// sortQuery gives Firestore.
+func orderImages(a, b *models.Image, filter *models.ImageFilter) int {
+	if filter.Order == models.OrderDesc {
+		return compareImages(b, a, filter.SortBy)
+	}
+	return compareImages(a, b, filter.SortBy)
+}
+
+// legacySubTypeField is where label updates stored the subtype before they
+// were fixed to use the sub_type field that the pipeline writes and the
+// model reads. A record with the field was updated after it was created, so
+// its value supersedes sub_type. MigrateSubTypes moves it to sub_type.
+const legacySubTypeField = "subtype"
+
+func imageFromDoc(doc *firestore.DocumentSnapshot) (*models.Image, error) {
+	var image models.Image
+	if err := doc.DataTo(&image); err != nil {
+		return nil, fmt.Errorf("failed to convert document to image: %w", err)
+	}
+	image.ID = doc.Ref.ID // Set the ID from the document reference
+	if legacy, err := doc.DataAt(legacySubTypeField); err == nil {
+		if subType, ok := legacy.(string); ok {
+			image.SubType = &subType
+		}
+	}
+	return &image, nil
+}
+
+// MigrateSubTypes moves subtypes stored under the legacy subtype field to
+// sub_type, in the active images and in the trash, and returns the number of
+// images moved. Images are written in batched writes that fail if an image
+// changed since it was read, in which case the migration can be run again.
+// The version is kept, since the labels themselves do not change.
+func (r *FirestoreImageRepository) MigrateSubTypes(ctx context.Context) (int, error) {
+	moved := 0
+	for _, collection := range []*firestore.CollectionRef{r.collection, r.trash} {
+		// Every string is >= "", so this matches documents with the field.
+		docs, err := collection.Where(legacySubTypeField, ">=", "").Limit(maxBatchOperations).Documents(ctx).GetAll()
+		for err == nil && len(docs) > 0 {
+			batch := r.client.Batch()
+			for _, doc := range docs {
+				subType, _ := doc.DataAt(legacySubTypeField)
+				batch.Update(doc.Ref, []firestore.Update{
+					{Path: "sub_type", Value: subType},
+					{Path: legacySubTypeField, Value: firestore.Delete},
+				}, firestore.LastUpdateTime(doc.UpdateTime))
+			}
+			if _, err = batch.Commit(ctx); err != nil {
+				break
+			}
+			moved += len(docs)
+			docs, err = collection.Where(legacySubTypeField, ">=", "").Limit(maxBatchOperations).Documents(ctx).GetAll()
+		}
+		if err != nil {
+			return moved, fmt.Errorf("failed to migrate subtypes: %w", translateError(err))
+		}
+	}
+	return moved, nil
 }
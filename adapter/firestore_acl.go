@@ -0,0 +1,84 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"cloud.google.com/go/firestore"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// Firestore limits "in" filters to 30 values.
+const firestoreInLimit = 30
+
+type FirestoreACLRepository struct {
+	client     *firestore.Client
+	collection *firestore.CollectionRef
+}
+
+func NewFirestoreACLRepository(client *firestore.Client, collectionName string) (*FirestoreACLRepository, error) {
+	return &FirestoreACLRepository{
+		client:     client,
+		collection: client.Collection(collectionName),
+	}, nil
+}
+
+func (r *FirestoreACLRepository) ListBySubjects(ctx context.Context, subjects []string) ([]*models.DatasetGrant, error) {
+	var grants []*models.DatasetGrant
+	for start := 0; start < len(subjects); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(subjects))
+		docs, err := r.collection.Where("subject", "in", subjects[start:end]).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list grants: %w", translateError(err))
+		}
+		batch, err := grantsFromDocs(docs)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, batch...)
+	}
+	return grants, nil
+}
+
+func (r *FirestoreACLRepository) ListByDataset(ctx context.Context, datasetName string) ([]*models.DatasetGrant, error) {
+	docs, err := r.collection.Where("dataset_name", "==", datasetName).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", translateError(err))
+	}
+	return grantsFromDocs(docs)
+}
+
+func (r *FirestoreACLRepository) Put(ctx context.Context, grant *models.DatasetGrant) error {
+	_, err := r.collection.Doc(grantDocID(grant.DatasetName, grant.Subject)).Set(ctx, grant)
+	if err != nil {
+		return fmt.Errorf("failed to store grant: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r *FirestoreACLRepository) Delete(ctx context.Context, datasetName, subject string) error {
+	_, err := r.collection.Doc(grantDocID(datasetName, subject)).Delete(ctx, firestore.Exists)
+	if err != nil {
+		return fmt.Errorf("failed to delete grant: %w", translateError(err))
+	}
+	return nil
+}
+
+// grantDocID derives a deterministic document ID so each dataset and subject
+// pair has at most one grant.
+func grantDocID(datasetName, subject string) string {
+	return url.PathEscape(datasetName) + "|" + url.PathEscape(subject)
+}
+
+func grantsFromDocs(docs []*firestore.DocumentSnapshot) ([]*models.DatasetGrant, error) {
+	grants := make([]*models.DatasetGrant, 0, len(docs))
+	for _, doc := range docs {
+		var grant models.DatasetGrant
+		if err := doc.DataTo(&grant); err != nil {
+			return nil, fmt.Errorf("failed to convert document to grant: %w", err)
+		}
+		grants = append(grants, &grant)
+	}
+	return grants, nil
+}
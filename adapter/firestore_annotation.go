@@ -0,0 +1,147 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreAnnotationRepository keeps annotations in a top-level collection
+// rather than below their image, because images move between collections
+// when they are trashed and restored.
+type FirestoreAnnotationRepository struct {
+	client     *firestore.Client
+	collection *firestore.CollectionRef
+}
+
+func NewFirestoreAnnotationRepository(client *firestore.Client, collectionName string) (*FirestoreAnnotationRepository, error) {
+	return &FirestoreAnnotationRepository{
+		client:     client,
+		collection: client.Collection(collectionName),
+	}, nil
+}
+
+func (r *FirestoreAnnotationRepository) Create(ctx context.Context, annotation *models.Annotation) error {
+	doc := r.collection.NewDoc()
+	if _, err := doc.Create(ctx, annotation); err != nil {
+		return fmt.Errorf("failed to create annotation: %w", translateError(err))
+	}
+	annotation.ID = doc.ID
+	return nil
+}
+
+func (r *FirestoreAnnotationRepository) Read(ctx context.Context, imageID, annotationID string) (*models.Annotation, error) {
+	doc, err := r.collection.Doc(annotationID).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotation: %w", translateError(err))
+	}
+	annotation, err := annotationFromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if annotation.ImageID != imageID {
+		return nil, fmt.Errorf("failed to read annotation: %w", translateError(status.Errorf(codes.NotFound, "annotation %q not found", annotationID)))
+	}
+	return annotation, nil
+}
+
+func (r *FirestoreAnnotationRepository) Update(ctx context.Context, annotation *models.Annotation) error {
+	_, err := r.collection.Doc(annotation.ID).Update(ctx, []firestore.Update{
+		{Path: "label", Value: annotation.Label},
+		{Path: "geometry", Value: annotation.Geometry},
+		{Path: "updated_by", Value: annotation.UpdatedBy},
+		{Path: "updated_at", Value: annotation.UpdatedAt},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update annotation: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r *FirestoreAnnotationRepository) Delete(ctx context.Context, imageID, annotationID string) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc := r.collection.Doc(annotationID)
+		snapshot, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		if id, _ := snapshot.DataAt("image_id"); id != imageID {
+			return status.Errorf(codes.NotFound, "annotation %q not found", annotationID)
+		}
+		return tx.Delete(doc)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r *FirestoreAnnotationRepository) ListByImage(ctx context.Context, imageID string) ([]*models.Annotation, error) {
+	// Sorted here rather than in the query so no composite index is needed.
+	docs, err := r.collection.Where("image_id", "==", imageID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", translateError(err))
+	}
+	annotations := make([]*models.Annotation, 0, len(docs))
+	for _, doc := range docs {
+		annotation, err := annotationFromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, annotation)
+	}
+	slices.SortFunc(annotations, compareAnnotations)
+	return annotations, nil
+}
+
+func (r *FirestoreAnnotationRepository) DeleteByImage(ctx context.Context, imageID string) error {
+	docs, err := r.collection.Where("image_id", "==", imageID).Select().Documents(ctx).GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list annotations: %w", translateError(err))
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	bulk := r.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		job, err := bulk.Delete(doc.Ref)
+		if err != nil {
+			bulk.End()
+			return fmt.Errorf("failed to delete annotations: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	bulk.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return fmt.Errorf("failed to delete annotations: %w", translateError(err))
+		}
+	}
+	return nil
+}
+
+func annotationFromDoc(doc *firestore.DocumentSnapshot) (*models.Annotation, error) {
+	var annotation models.Annotation
+	if err := doc.DataTo(&annotation); err != nil {
+		return nil, fmt.Errorf("failed to convert document to annotation: %w", err)
+	}
+	annotation.ID = doc.Ref.ID
+	return &annotation, nil
+}
+
+// compareAnnotations orders annotations by creation time, then ID.
+func compareAnnotations(a, b *models.Annotation) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
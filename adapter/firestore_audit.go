@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// FirestoreAuditRepository queries audit entries in their own collection.
+// FirestoreImageRepository creates them in its transactions; entries are
+// never changed afterwards, so Firestore security rules can deny updates and
+// deletes on the collection outright.
+type FirestoreAuditRepository struct {
+	client     *firestore.Client
+	collection *firestore.CollectionRef
+}
+
+func NewFirestoreAuditRepository(client *firestore.Client, collectionName string) (*FirestoreAuditRepository, error) {
+	return &FirestoreAuditRepository{
+		client:     client,
+		collection: client.Collection(collectionName),
+	}, nil
+}
+
+func (r *FirestoreAuditRepository) Query(ctx context.Context, filter *models.AuditFilter) (*models.AuditList, error) {
+	query := r.collection.Query
+	if filter.ImageID != "" {
+		query = query.Where("image_id", "==", filter.ImageID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor", "==", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("timestamp", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("timestamp", "<", filter.To)
+	}
+	query = query.OrderBy("timestamp", firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc)
+
+	if filter.PageToken != "" {
+		cursor, err := models.DecodeAuditCursor(filter.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		query = query.StartAfter(cursor.Timestamp, cursor.ID)
+	}
+
+	// Fetch one extra document to find out whether another page exists.
+	docs, err := query.Limit(filter.Limit + 1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", translateError(err))
+	}
+
+	list := &models.AuditList{Entries: []*models.AuditEntry{}}
+	for i, doc := range docs {
+		if i == filter.Limit {
+			last := list.Entries[len(list.Entries)-1]
+			list.NextPageToken = (&models.AuditCursor{Timestamp: last.Timestamp, ID: last.ID}).Encode()
+			break
+		}
+		var entry models.AuditEntry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, fmt.Errorf("failed to convert document to audit entry: %w", err)
+		}
+		entry.ID = doc.Ref.ID
+		list.Entries = append(list.Entries, &entry)
+	}
+	return list, nil
+}
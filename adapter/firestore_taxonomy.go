@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// FirestoreTaxonomyRepository stores each taxonomy in a document named
+// after its organ type.
+type FirestoreTaxonomyRepository struct {
+	client     *firestore.Client
+	collection *firestore.CollectionRef
+}
+
+func NewFirestoreTaxonomyRepository(client *firestore.Client, collectionName string) (*FirestoreTaxonomyRepository, error) {
+	return &FirestoreTaxonomyRepository{
+		client:     client,
+		collection: client.Collection(collectionName),
+	}, nil
+}
+
+func (r *FirestoreTaxonomyRepository) Read(ctx context.Context, organType string) (*models.Taxonomy, error) {
+	doc, err := r.collection.Doc(organType).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taxonomy: %w", translateError(err))
+	}
+	return taxonomyFromDoc(doc)
+}
+
+func (r *FirestoreTaxonomyRepository) List(ctx context.Context) ([]*models.Taxonomy, error) {
+	docs, err := r.collection.OrderBy(firestore.DocumentID, firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list taxonomies: %w", translateError(err))
+	}
+	taxonomies := make([]*models.Taxonomy, 0, len(docs))
+	for _, doc := range docs {
+		taxonomy, err := taxonomyFromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		taxonomies = append(taxonomies, taxonomy)
+	}
+	return taxonomies, nil
+}
+
+func (r *FirestoreTaxonomyRepository) Put(ctx context.Context, taxonomy *models.Taxonomy) error {
+	_, err := r.collection.Doc(taxonomy.OrganType).Set(ctx, taxonomy)
+	if err != nil {
+		return fmt.Errorf("failed to store taxonomy: %w", translateError(err))
+	}
+	return nil
+}
+
+func (r *FirestoreTaxonomyRepository) Delete(ctx context.Context, organType string) error {
+	_, err := r.collection.Doc(organType).Delete(ctx, firestore.Exists)
+	if err != nil {
+		return fmt.Errorf("failed to delete taxonomy: %w", translateError(err))
+	}
+	return nil
+}
+
+func taxonomyFromDoc(doc *firestore.DocumentSnapshot) (*models.Taxonomy, error) {
+	var taxonomy models.Taxonomy
+	if err := doc.DataTo(&taxonomy); err != nil {
+		return nil, fmt.Errorf("failed to convert document to taxonomy: %w", err)
+	}
+	taxonomy.OrganType = doc.Ref.ID
+	return &taxonomy, nil
+}
@@ -0,0 +1,111 @@
+package adapter
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"google.golang.org/api/iterator"
+)
+
+// GCSObjectStore is an ObjectStore backed by a Google Cloud Storage bucket.
+// Object versions are GCS generations.
+type GCSObjectStore struct {
+	client *storage.Client
+	bucket *storage.BucketHandle
+}
+
+func NewGCSObjectStore(client *storage.Client, bucketName string) *GCSObjectStore {
+	return &GCSObjectStore{
+		client: client,
+		bucket: client.Bucket(bucketName),
+	}
+}
+
+func (s *GCSObjectStore) Stat(ctx context.Context, name string) (*repository.ObjectAttrs, error) {
+	attrs, err := s.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object attributes: %w", translateError(err))
+	}
+	return &repository.ObjectAttrs{
+		Name:         attrs.Name,
+		ContentType:  attrs.ContentType,
+		Size:         attrs.Size,
+		ETag:         gcsETag(attrs),
+		LastModified: attrs.Updated,
+		Version:      strconv.FormatInt(attrs.Generation, 10),
+	}, nil
+}
+
+func (s *GCSObjectStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.OpenRange(ctx, name, "", 0, -1)
+}
+
+func (s *GCSObjectStore) OpenRange(ctx context.Context, name, version string, offset, length int64) (io.ReadCloser, error) {
+	object := s.bucket.Object(name)
+	if version != "" {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return nil, models.NewError(models.ErrValidation, "invalid object version %q", version)
+		}
+		object = object.Generation(generation)
+	}
+
+	reader, err := object.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", translateError(err))
+	}
+	return reader, nil
+}
+
+func (s *GCSObjectStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", translateError(err))
+		}
+		names = append(names, attrs.Name)
+	}
+	return names, nil
+}
+
+func (s *GCSObjectStore) Delete(ctx context.Context, name string) error {
+	err := s.bucket.Object(name).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete object: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *GCSObjectStore) Write(ctx context.Context, name, contentType string, r io.Reader) error {
+	writer := s.bucket.Object(name).NewWriter(ctx)
+	writer.ContentType = contentType
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+	return nil
+}
+
+// gcsETag derives a strong entity tag from the object's MD5 hash, or from
+// its generation for composite objects, which have no MD5.
+func gcsETag(attrs *storage.ObjectAttrs) string {
+	if len(attrs.MD5) > 0 {
+		return `"` + hex.EncodeToString(attrs.MD5) + `"`
+	}
+	return `"` + strconv.FormatInt(attrs.Generation, 10) + `"`
+}
@@ -0,0 +1,473 @@
+package adapter
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MemoryImageRepository is a thread-safe, in-memory ImageRepository used for
+// local development and tests. It mirrors the semantics of
+// FirestoreImageRepository, including the gRPC status codes of its errors.
+type MemoryImageRepository struct {
+	mu     sync.RWMutex
+	images map[string]*models.Image
+	trash  map[string]*models.Image
+	audit  *MemoryAuditRepository // Receives the audit entries of every change
+}
+
+func NewMemoryImageRepository(audit *MemoryAuditRepository, images ...*models.Image) *MemoryImageRepository {
+	repo := &MemoryImageRepository{
+		images: make(map[string]*models.Image, len(images)),
+		trash:  make(map[string]*models.Image),
+		audit:  audit,
+	}
+	for _, image := range images {
+		repo.images[image.ID] = cloneImage(image)
+	}
+	return repo
+}
+
+func (r *MemoryImageRepository) Create(ctx context.Context, image *models.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, collection := range []map[string]*models.Image{r.images, r.trash} {
+		for _, existing := range collection {
+			if existing.FileUID == image.FileUID {
+				return fmt.Errorf("failed to create image: %w", translateError(status.Errorf(codes.AlreadyExists, "image with file_uid %q already exists", image.FileUID)))
+			}
+		}
+	}
+
+	image.ID = newMemoryID()
+	r.images[image.ID] = cloneImage(image)
+	return nil
+}
+
+func (r *MemoryImageRepository) Read(ctx context.Context, imageID string) (*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	image, ok := r.images[imageID]
+	if !ok {
+		return nil, fmt.Errorf("failed to read image: %w", translateError(status.Errorf(codes.NotFound, "image %q not found", imageID)))
+	}
+	return cloneImage(image), nil
+}
+
+func (r *MemoryImageRepository) Update(ctx context.Context, image *models.Image, version int64, entries []*models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.images[image.ID]
+	if !ok {
+		return fmt.Errorf("failed to update image: %w", translateError(status.Errorf(codes.NotFound, "image %q not found", image.ID)))
+	}
+	if stored.Version != version {
+		return fmt.Errorf("failed to update image: %w", models.NewError(models.ErrPrecondition, "image %q is at version %d, not %d", image.ID, stored.Version, version))
+	}
+
+	writeLabels(stored, image, version+1)
+	stored.DatasetName, stored.OrganType = image.DatasetName, image.OrganType
+	image.Version = stored.Version
+	r.audit.add(entries)
+	return nil
+}
+
+func (r *MemoryImageRepository) ReadMany(ctx context.Context, imageIDs []string) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, id := range imageIDs {
+		if image, ok := r.images[id]; ok {
+			images = append(images, cloneImage(image))
+		}
+	}
+	return images, nil
+}
+
+func (r *MemoryImageRepository) ListByField(ctx context.Context, field string, values []string) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(values))
+	for _, value := range values {
+		wanted[value] = true
+	}
+	var images []*models.Image
+	for _, image := range r.images {
+		value := image.FileUID
+		if field == models.ImportMatchFileName {
+			value = image.FileName
+		}
+		if wanted[value] {
+			images = append(images, cloneImage(image))
+		}
+	}
+	return images, nil
+}
+
+func (r *MemoryImageRepository) UpdateBatch(ctx context.Context, images []*models.Image, entries []*models.AuditEntry) (map[string]error, error) {
+	if len(images) > models.MaxBatchWrites {
+		return nil, models.NewError(models.ErrValidation, "at most %d images can be written in one batch", models.MaxBatchWrites)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	skipped := make(map[string]error)
+	for _, image := range images {
+		stored, ok := r.images[image.ID]
+		if !ok {
+			skipped[image.ID] = models.NewError(models.ErrNotFound, "image %q not found", image.ID)
+			continue
+		}
+		if stored.Version != image.Version {
+			skipped[image.ID] = models.NewError(models.ErrPrecondition, "image %q is at version %d, not %d", image.ID, stored.Version, image.Version)
+			continue
+		}
+		writeLabels(stored, image, image.Version+1)
+		image.Version = stored.Version
+	}
+	written := make([]*models.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		if skipped[entry.ImageID] == nil {
+			written = append(written, entry)
+		}
+	}
+	r.audit.add(written)
+	return skipped, nil
+}
+
+// writeLabels copies the label fields of image to the stored record and sets
+// its version. Only these fields are written, matching FirestoreImageRepository.
+func writeLabels(stored, image *models.Image, version int64) {
+	if image.DiseaseType != nil {
+		stored.DiseaseType = cloneString(image.DiseaseType)
+	}
+	if image.Classification != nil {
+		stored.Classification = cloneString(image.Classification)
+	}
+	if image.SubType != nil {
+		stored.SubType = cloneString(image.SubType)
+	}
+	if image.Grade != nil {
+		stored.Grade = cloneString(image.Grade)
+	}
+	stored.UpdatedAt = image.UpdatedAt
+	stored.Version = version
+}
+
+func (r *MemoryImageRepository) Delete(ctx context.Context, imageID string, entries []*models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Deleting a missing document is not an error in Firestore either.
+	delete(r.images, imageID)
+	delete(r.trash, imageID)
+	r.audit.add(entries)
+	return nil
+}
+
+func (r *MemoryImageRepository) SoftDelete(ctx context.Context, imageID, deletedBy string, deletedAt time.Time, entries []*models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[imageID]
+	if !ok {
+		return fmt.Errorf("failed to soft delete image: %w", translateError(status.Errorf(codes.NotFound, "image %q not found", imageID)))
+	}
+
+	image.DeletedAt = &deletedAt
+	image.DeletedBy = deletedBy
+	r.trash[imageID] = image
+	delete(r.images, imageID)
+	r.audit.add(entries)
+	return nil
+}
+
+func (r *MemoryImageRepository) Restore(ctx context.Context, imageID string, entries []*models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.trash[imageID]
+	if !ok {
+		return fmt.Errorf("failed to restore image: %w", translateError(status.Errorf(codes.NotFound, "image %q not found in trash", imageID)))
+	}
+	if _, exists := r.images[imageID]; exists {
+		return fmt.Errorf("failed to restore image: %w", translateError(status.Errorf(codes.AlreadyExists, "image %q already exists", imageID)))
+	}
+
+	image.DeletedAt = nil
+	image.DeletedBy = ""
+	r.images[imageID] = image
+	delete(r.trash, imageID)
+	for _, entry := range entries {
+		entry.DatasetName = image.DatasetName
+	}
+	r.audit.add(entries)
+	return nil
+}
+
+func (r *MemoryImageRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, image := range r.trash {
+		if image.DeletedAt != nil && image.DeletedAt.Before(cutoff) {
+			images = append(images, image)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].DeletedAt.Before(*images[j].DeletedAt)
+	})
+	if len(images) > limit {
+		images = images[:limit]
+	}
+	for i, image := range images {
+		images[i] = cloneImage(image)
+	}
+	return images, nil
+}
+
+func (r *MemoryImageRepository) Filter(ctx context.Context, filter *models.ImageFilter) (*models.ImageList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := r.matching(filter)
+	descending := filter.Order == models.OrderDesc
+
+	start := 0
+	if filter.PageToken != "" {
+		cursor, err := models.DecodePageCursor(filter.PageToken, filter.SortBy, filter.Order)
+		if err != nil {
+			return nil, err
+		}
+		// Position the listing just after the cursor, even if the image it
+		// points to has since been deleted.
+		start = sort.Search(len(matched), func(i int) bool {
+			c := compareToCursor(matched[i], cursor)
+			if descending {
+				return c < 0
+			}
+			return c > 0
+		})
+	}
+
+	list := &models.ImageList{Images: []*models.Image{}, TotalCount: int64(len(matched))}
+	end := start + filter.PageSize()
+	if end < len(matched) {
+		list.NextPageToken = models.NewPageCursor(matched[end-1], filter.SortBy, filter.Order).Encode()
+	} else {
+		end = len(matched)
+	}
+	for _, image := range matched[start:end] {
+		list.Images = append(list.Images, cloneImage(image))
+	}
+	return list, nil
+}
+
+func (r *MemoryImageRepository) Count(ctx context.Context, filter *models.ImageFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total int64
+	collection := r.images
+	if filter.Deleted {
+		collection = r.trash
+	}
+	for _, image := range collection {
+		if matchesFilter(image, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// Iterate copies the matching images while holding the lock and calls fn
+// after releasing it, so fn may use the repository.
+func (r *MemoryImageRepository) Iterate(ctx context.Context, filter *models.ImageFilter, fn func(*models.Image) error) error {
+	r.mu.RLock()
+	matched := r.matching(filter)
+	for i, image := range matched {
+		matched[i] = cloneImage(image)
+	}
+	r.mu.RUnlock()
+
+	for _, image := range matched {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matching returns the stored images that match the filter in its sort
+// order. The caller must hold the lock.
+func (r *MemoryImageRepository) matching(filter *models.ImageFilter) []*models.Image {
+	collection := r.images
+	if filter.Deleted {
+		collection = r.trash
+	}
+
+	var matched []*models.Image
+	for _, image := range collection {
+		if matchesFilter(image, filter) {
+			matched = append(matched, image)
+		}
+	}
+
+	descending := filter.Order == models.OrderDesc
+	sort.Slice(matched, func(i, j int) bool {
+		c := compareImages(matched[i], matched[j], filter.SortBy)
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	return matched
+}
+
+func (r *MemoryImageRepository) FindByObjectPath(ctx context.Context, objectName string) (*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, image := range r.images {
+		if image.OwnsObject(objectName) {
+			return cloneImage(image), nil
+		}
+	}
+	return nil, models.NewError(models.ErrNotFound, "no image owns object %q", objectName)
+}
+
+func (r *MemoryImageRepository) ListOverlapping(ctx context.Context, prefix string) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, collection := range []map[string]*models.Image{r.images, r.trash} {
+		for _, image := range collection {
+			if image.OverlapsPrefix(prefix) {
+				images = append(images, cloneImage(image))
+			}
+		}
+	}
+	return images, nil
+}
+
+// compareImages orders two images by the sort field, breaking ties by ID as
+// Firestore does with its implicit document ID ordering.
+func compareImages(a, b *models.Image, sortBy string) int {
+	var c int
+	switch sortBy {
+	case models.SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case models.SortByFileName:
+		c = strings.Compare(a.FileName, b.FileName)
+	case models.SortBySize:
+		c = cmp.Compare(a.Size, b.Size)
+	case models.SortByWidth:
+		c = cmp.Compare(a.Width, b.Width)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// compareToCursor compares an image against the position recorded in a page cursor.
+func compareToCursor(image *models.Image, cursor *models.PageCursor) int {
+	value, _ := cursor.SortValue()
+
+	var c int
+	switch cursor.SortBy {
+	case models.SortByCreatedAt:
+		c = image.CreatedAt.Compare(value.(time.Time))
+	case models.SortByFileName:
+		c = strings.Compare(image.FileName, value.(string))
+	case models.SortBySize:
+		c = cmp.Compare(image.Size, value.(int64))
+	case models.SortByWidth:
+		c = cmp.Compare(int64(image.Width), value.(int64))
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(image.ID, cursor.ID)
+}
+
+func matchesFilter(image *models.Image, filter *models.ImageFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if len(filter.DatasetNames) > 0 && !slices.Contains(filter.DatasetNames, image.DatasetName) {
+		return false
+	}
+	return matchesValue(&image.DatasetName, filter.DatasetName) &&
+		matchesValue(&image.OrganType, filter.OrganType) &&
+		matchesValue(image.DiseaseType, filter.DiseaseType) &&
+		matchesValue(image.Classification, filter.Classification) &&
+		matchesValue(image.SubType, filter.SubType) &&
+		matchesValue(image.Grade, filter.Grade)
+}
+
+// matchesValue treats a nil or empty filter value as "match anything",
+// the same way FirestoreImageRepository.Filter skips those conditions.
+func matchesValue(value, want *string) bool {
+	if want == nil || *want == "" {
+		return true
+	}
+	return value != nil && *value == *want
+}
+
+// newMemoryID returns a random document ID in the same alphabet and length
+// that Firestore uses for auto-generated IDs.
+func newMemoryID() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	id := make([]byte, 20)
+	for i := range id {
+		id[i] = alphabet[rand.IntN(len(alphabet))]
+	}
+	return string(id)
+}
+
+func cloneImage(image *models.Image) *models.Image {
+	clone := *image
+	clone.DiseaseType = cloneString(image.DiseaseType)
+	clone.Classification = cloneString(image.Classification)
+	clone.SubType = cloneString(image.SubType)
+	clone.Grade = cloneString(image.Grade)
+	if image.Overlap != nil {
+		overlap := *image.Overlap
+		clone.Overlap = &overlap
+	}
+	if image.DeletedAt != nil {
+		deletedAt := *image.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
@@ -0,0 +1,77 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MemoryACLRepository is a thread-safe, in-memory ACLRepository used for
+// local development and tests.
+type MemoryACLRepository struct {
+	mu     sync.RWMutex
+	grants map[string]*models.DatasetGrant
+}
+
+func NewMemoryACLRepository(grants ...*models.DatasetGrant) *MemoryACLRepository {
+	repo := &MemoryACLRepository{
+		grants: make(map[string]*models.DatasetGrant, len(grants)),
+	}
+	for _, grant := range grants {
+		clone := *grant
+		repo.grants[grantDocID(grant.DatasetName, grant.Subject)] = &clone
+	}
+	return repo
+}
+
+func (r *MemoryACLRepository) ListBySubjects(ctx context.Context, subjects []string) ([]*models.DatasetGrant, error) {
+	return r.list(func(grant *models.DatasetGrant) bool {
+		return slices.Contains(subjects, grant.Subject)
+	}), nil
+}
+
+func (r *MemoryACLRepository) ListByDataset(ctx context.Context, datasetName string) ([]*models.DatasetGrant, error) {
+	return r.list(func(grant *models.DatasetGrant) bool {
+		return grant.DatasetName == datasetName
+	}), nil
+}
+
+func (r *MemoryACLRepository) Put(ctx context.Context, grant *models.DatasetGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := *grant
+	r.grants[grantDocID(grant.DatasetName, grant.Subject)] = &clone
+	return nil
+}
+
+func (r *MemoryACLRepository) Delete(ctx context.Context, datasetName, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := grantDocID(datasetName, subject)
+	if _, ok := r.grants[id]; !ok {
+		return fmt.Errorf("failed to delete grant: %w", translateError(status.Errorf(codes.NotFound, "grant for %q on %q not found", subject, datasetName)))
+	}
+	delete(r.grants, id)
+	return nil
+}
+
+func (r *MemoryACLRepository) list(match func(*models.DatasetGrant) bool) []*models.DatasetGrant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var grants []*models.DatasetGrant
+	for _, grant := range r.grants {
+		if match(grant) {
+			clone := *grant
+			grants = append(grants, &clone)
+		}
+	}
+	return grants
+}
@@ -0,0 +1,107 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MemoryAnnotationRepository is a thread-safe, in-memory AnnotationRepository
+// used for local development and tests.
+type MemoryAnnotationRepository struct {
+	mu          sync.RWMutex
+	annotations map[string]*models.Annotation
+}
+
+func NewMemoryAnnotationRepository() *MemoryAnnotationRepository {
+	return &MemoryAnnotationRepository{
+		annotations: make(map[string]*models.Annotation),
+	}
+}
+
+func (r *MemoryAnnotationRepository) Create(ctx context.Context, annotation *models.Annotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	annotation.ID = newMemoryID()
+	r.annotations[annotation.ID] = cloneAnnotation(annotation)
+	return nil
+}
+
+func (r *MemoryAnnotationRepository) Read(ctx context.Context, imageID, annotationID string) (*models.Annotation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	annotation, ok := r.annotations[annotationID]
+	if !ok || annotation.ImageID != imageID {
+		return nil, fmt.Errorf("failed to read annotation: %w", translateError(status.Errorf(codes.NotFound, "annotation %q not found", annotationID)))
+	}
+	return cloneAnnotation(annotation), nil
+}
+
+func (r *MemoryAnnotationRepository) Update(ctx context.Context, annotation *models.Annotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.annotations[annotation.ID]
+	if !ok {
+		return fmt.Errorf("failed to update annotation: %w", translateError(status.Errorf(codes.NotFound, "annotation %q not found", annotation.ID)))
+	}
+	updated := cloneAnnotation(existing)
+	updated.Label = annotation.Label
+	updated.Geometry.Type = annotation.Geometry.Type
+	updated.Geometry.Points = slices.Clone(annotation.Geometry.Points)
+	updated.UpdatedBy = annotation.UpdatedBy
+	updated.UpdatedAt = annotation.UpdatedAt
+	r.annotations[annotation.ID] = updated
+	return nil
+}
+
+func (r *MemoryAnnotationRepository) Delete(ctx context.Context, imageID, annotationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	annotation, ok := r.annotations[annotationID]
+	if !ok || annotation.ImageID != imageID {
+		return fmt.Errorf("failed to delete annotation: %w", translateError(status.Errorf(codes.NotFound, "annotation %q not found", annotationID)))
+	}
+	delete(r.annotations, annotationID)
+	return nil
+}
+
+func (r *MemoryAnnotationRepository) ListByImage(ctx context.Context, imageID string) ([]*models.Annotation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	annotations := []*models.Annotation{}
+	for _, annotation := range r.annotations {
+		if annotation.ImageID == imageID {
+			annotations = append(annotations, cloneAnnotation(annotation))
+		}
+	}
+	slices.SortFunc(annotations, compareAnnotations)
+	return annotations, nil
+}
+
+func (r *MemoryAnnotationRepository) DeleteByImage(ctx context.Context, imageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, annotation := range r.annotations {
+		if annotation.ImageID == imageID {
+			delete(r.annotations, id)
+		}
+	}
+	return nil
+}
+
+func cloneAnnotation(annotation *models.Annotation) *models.Annotation {
+	clone := *annotation
+	clone.Geometry.Points = slices.Clone(annotation.Geometry.Points)
+	return &clone
+}
@@ -0,0 +1,91 @@
+package adapter
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// MemoryAuditRepository is a thread-safe, in-memory AuditRepository used for
+// local development and tests.
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []*models.AuditEntry
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+// add stores the entries and assigns their IDs. It is called by
+// MemoryImageRepository while it holds its own lock, so the entries appear
+// together with the change they describe.
+func (r *MemoryAuditRepository) add(entries []*models.AuditEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.ID = newMemoryID()
+		r.entries = append(r.entries, cloneAuditEntry(entry))
+	}
+}
+
+func (r *MemoryAuditRepository) Query(ctx context.Context, filter *models.AuditFilter) (*models.AuditList, error) {
+	var cursor *models.AuditCursor
+	if filter.PageToken != "" {
+		var err error
+		if cursor, err = models.DecodeAuditCursor(filter.PageToken); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []*models.AuditEntry
+	for _, entry := range r.entries {
+		switch {
+		case filter.ImageID != "" && entry.ImageID != filter.ImageID,
+			filter.Actor != "" && entry.Actor != filter.Actor,
+			!filter.From.IsZero() && entry.Timestamp.Before(filter.From),
+			!filter.To.IsZero() && !entry.Timestamp.Before(filter.To),
+			cursor != nil && compareAuditEntry(entry, cursor.Timestamp, cursor.ID) >= 0:
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	// Newest first, as Firestore orders them.
+	slices.SortFunc(matches, func(a, b *models.AuditEntry) int {
+		return compareAuditEntry(b, a.Timestamp, a.ID)
+	})
+
+	list := &models.AuditList{Entries: []*models.AuditEntry{}}
+	for i, entry := range matches {
+		if i == filter.Limit {
+			last := list.Entries[len(list.Entries)-1]
+			list.NextPageToken = (&models.AuditCursor{Timestamp: last.Timestamp, ID: last.ID}).Encode()
+			break
+		}
+		list.Entries = append(list.Entries, cloneAuditEntry(entry))
+	}
+	return list, nil
+}
+
+// compareAuditEntry orders an entry against a timestamp and ID, oldest first.
+func compareAuditEntry(entry *models.AuditEntry, timestamp time.Time, id string) int {
+	if c := entry.Timestamp.Compare(timestamp); c != 0 {
+		return c
+	}
+	return strings.Compare(entry.ID, id)
+}
+
+func cloneAuditEntry(entry *models.AuditEntry) *models.AuditEntry {
+	clone := *entry
+	clone.OldValue = cloneString(entry.OldValue)
+	clone.NewValue = cloneString(entry.NewValue)
+	return &clone
+}
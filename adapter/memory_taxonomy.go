@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MemoryTaxonomyRepository is a thread-safe, in-memory TaxonomyRepository
+// used for local development and tests. Taxonomies are replaced as a whole
+// and never modified in place, so only the top-level struct is copied.
+type MemoryTaxonomyRepository struct {
+	mu         sync.RWMutex
+	taxonomies map[string]*models.Taxonomy
+}
+
+func NewMemoryTaxonomyRepository(taxonomies ...*models.Taxonomy) *MemoryTaxonomyRepository {
+	repo := &MemoryTaxonomyRepository{
+		taxonomies: make(map[string]*models.Taxonomy, len(taxonomies)),
+	}
+	for _, taxonomy := range taxonomies {
+		clone := *taxonomy
+		repo.taxonomies[taxonomy.OrganType] = &clone
+	}
+	return repo
+}
+
+func (r *MemoryTaxonomyRepository) Read(ctx context.Context, organType string) (*models.Taxonomy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	taxonomy, ok := r.taxonomies[organType]
+	if !ok {
+		return nil, fmt.Errorf("failed to read taxonomy: %w", translateError(status.Errorf(codes.NotFound, "taxonomy for %q not found", organType)))
+	}
+	clone := *taxonomy
+	return &clone, nil
+}
+
+func (r *MemoryTaxonomyRepository) List(ctx context.Context) ([]*models.Taxonomy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	taxonomies := make([]*models.Taxonomy, 0, len(r.taxonomies))
+	for _, taxonomy := range r.taxonomies {
+		clone := *taxonomy
+		taxonomies = append(taxonomies, &clone)
+	}
+	slices.SortFunc(taxonomies, func(a, b *models.Taxonomy) int {
+		return strings.Compare(a.OrganType, b.OrganType)
+	})
+	return taxonomies, nil
+}
+
+func (r *MemoryTaxonomyRepository) Put(ctx context.Context, taxonomy *models.Taxonomy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := *taxonomy
+	r.taxonomies[taxonomy.OrganType] = &clone
+	return nil
+}
+
+func (r *MemoryTaxonomyRepository) Delete(ctx context.Context, organType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.taxonomies[organType]; !ok {
+		return fmt.Errorf("failed to delete taxonomy: %w", translateError(status.Errorf(codes.NotFound, "taxonomy for %q not found", organType)))
+	}
+	delete(r.taxonomies, organType)
+	return nil
+}
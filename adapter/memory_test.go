@@ -0,0 +1,233 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+func label(value string) *string { return &value }
+
+// memoryImages returns images of which some share a sort key, so that
+// listings also exercise the tie break by ID.
+func memoryImages() []*models.Image {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*models.Image{
+		{ID: "a", DatasetName: "breast", OrganType: "breast", DiseaseType: label("carcinoma"), FileName: "03.svs", Size: 300, Width: 2000, CreatedAt: created},
+		{ID: "b", DatasetName: "breast", OrganType: "breast", DiseaseType: label("carcinoma"), SubType: label("ductal"), FileName: "01.svs", Size: 100, Width: 1000, CreatedAt: created.Add(time.Hour)},
+		{ID: "c", DatasetName: "breast", OrganType: "lung", DiseaseType: label("adenocarcinoma"), FileName: "02.svs", Size: 100, Width: 3000, CreatedAt: created.Add(2 * time.Hour)},
+		{ID: "d", DatasetName: "colon", OrganType: "colon", FileName: "02.svs", Size: 200, Width: 1000, CreatedAt: created.Add(time.Hour)},
+		{ID: "e", DatasetName: "lung", OrganType: "lung", DiseaseType: label("adenocarcinoma"), Grade: label("2"), FileName: "04.svs", Size: 500, Width: 4000, CreatedAt: created.Add(3 * time.Hour)},
+	}
+}
+
+func imageIDs(images []*models.Image) []string {
+	ids := make([]string, len(images))
+	for i, image := range images {
+		ids[i] = image.ID
+	}
+	return ids
+}
+
+func TestMemoryImageRepositoryFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.ImageFilter
+		want   []string
+	}{
+		{
+			name:   "created_at desc",
+			filter: models.ImageFilter{SortBy: models.SortByCreatedAt, Order: models.OrderDesc},
+			want:   []string{"e", "c", "d", "b", "a"},
+		},
+		{
+			name:   "created_at asc",
+			filter: models.ImageFilter{SortBy: models.SortByCreatedAt, Order: models.OrderAsc},
+			want:   []string{"a", "b", "d", "c", "e"},
+		},
+		{
+			name:   "file_name asc",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc},
+			want:   []string{"b", "c", "d", "a", "e"},
+		},
+		{
+			name:   "size desc",
+			filter: models.ImageFilter{SortBy: models.SortBySize, Order: models.OrderDesc},
+			want:   []string{"e", "a", "d", "c", "b"},
+		},
+		{
+			name:   "width asc",
+			filter: models.ImageFilter{SortBy: models.SortByWidth, Order: models.OrderAsc},
+			want:   []string{"b", "d", "a", "c", "e"},
+		},
+		{
+			name:   "dataset",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, DatasetName: label("breast")},
+			want:   []string{"b", "c", "a"},
+		},
+		{
+			name:   "organ and disease",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, OrganType: label("lung"), DiseaseType: label("adenocarcinoma")},
+			want:   []string{"c", "e"},
+		},
+		{
+			name:   "sub type",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, SubType: label("ductal")},
+			want:   []string{"b"},
+		},
+		{
+			name:   "empty label matches anything",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, Grade: label("")},
+			want:   []string{"b", "c", "d", "a", "e"},
+		},
+		{
+			name:   "readable datasets",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, DatasetNames: []string{"colon", "lung"}},
+			want:   []string{"d", "e"},
+		},
+		{
+			name:   "readable datasets and dataset",
+			filter: models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, DatasetNames: []string{"colon"}, DatasetName: label("breast")},
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryImageRepository(NewMemoryAuditRepository(), memoryImages()...)
+			list, err := repo.Filter(context.Background(), &tt.filter)
+			if err != nil {
+				t.Fatalf("Filter() error = %v", err)
+			}
+			if got := imageIDs(list.Images); !slices.Equal(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+			if list.TotalCount != int64(len(tt.want)) {
+				t.Errorf("TotalCount = %d, want %d", list.TotalCount, len(tt.want))
+			}
+			if list.NextPageToken != "" {
+				t.Errorf("NextPageToken = %q, want none", list.NextPageToken)
+			}
+
+			count, err := repo.Count(context.Background(), &tt.filter)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if count != int64(len(tt.want)) {
+				t.Errorf("Count() = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryImageRepositoryFilterPages(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		order  string
+		limit  int
+		want   [][]string
+	}{
+		{sortBy: models.SortByCreatedAt, order: models.OrderDesc, limit: 2, want: [][]string{{"e", "c"}, {"d", "b"}, {"a"}}},
+		{sortBy: models.SortByFileName, order: models.OrderAsc, limit: 2, want: [][]string{{"b", "c"}, {"d", "a"}, {"e"}}},
+		{sortBy: models.SortBySize, order: models.OrderAsc, limit: 1, want: [][]string{{"b"}, {"c"}, {"d"}, {"a"}, {"e"}}},
+		{sortBy: models.SortByWidth, order: models.OrderDesc, limit: 3, want: [][]string{{"e", "c", "a"}, {"d", "b"}}},
+		{sortBy: models.SortByWidth, order: models.OrderDesc, limit: 5, want: [][]string{{"e", "c", "a", "d", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy+" "+tt.order, func(t *testing.T) {
+			repo := NewMemoryImageRepository(NewMemoryAuditRepository(), memoryImages()...)
+			filter := &models.ImageFilter{SortBy: tt.sortBy, Order: tt.order, Limit: tt.limit}
+
+			var pages [][]string
+			for {
+				list, err := repo.Filter(context.Background(), filter)
+				if err != nil {
+					t.Fatalf("Filter() error = %v", err)
+				}
+				pages = append(pages, imageIDs(list.Images))
+				if list.NextPageToken == "" {
+					break
+				}
+				if len(pages) > len(tt.want) {
+					t.Fatalf("Filter() returned more than %d pages: %v", len(tt.want), pages)
+				}
+				filter.PageToken = list.NextPageToken
+			}
+			if !slices.EqualFunc(pages, tt.want, slices.Equal) {
+				t.Errorf("pages = %v, want %v", pages, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryImageRepositoryFilterCursorAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryImageRepository(NewMemoryAuditRepository(), memoryImages()...)
+	filter := &models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, Limit: 2}
+
+	list, err := repo.Filter(ctx, filter)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	// The cursor points to "c", which is deleted before the next page is read.
+	if err := repo.Delete(ctx, "c", nil); err != nil {
+		t.Fatal(err)
+	}
+	filter.PageToken = list.NextPageToken
+	list, err = repo.Filter(ctx, filter)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if got, want := imageIDs(list.Images), []string{"d", "a"}; !slices.Equal(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryImageRepositoryFilterInvalidToken(t *testing.T) {
+	token := models.NewPageCursor(memoryImages()[0], models.SortBySize, models.OrderAsc).Encode()
+	tests := []struct {
+		name   string
+		filter models.ImageFilter
+	}{
+		{name: "garbage", filter: models.ImageFilter{SortBy: models.SortBySize, Order: models.OrderAsc, PageToken: "not a token"}},
+		{name: "other sort", filter: models.ImageFilter{SortBy: models.SortByWidth, Order: models.OrderAsc, PageToken: token}},
+		{name: "other order", filter: models.ImageFilter{SortBy: models.SortBySize, Order: models.OrderDesc, PageToken: token}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryImageRepository(NewMemoryAuditRepository(), memoryImages()...)
+			if _, err := repo.Filter(context.Background(), &tt.filter); !errors.Is(err, models.ErrValidation) {
+				t.Errorf("Filter() error = %v, want %v", err, models.ErrValidation)
+			}
+		})
+	}
+}
+
+func TestMemoryImageRepositoryFilterTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryImageRepository(NewMemoryAuditRepository(), memoryImages()...)
+	if err := repo.SoftDelete(ctx, "b", "admin", time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		deleted bool
+		want    []string
+	}{
+		{deleted: false, want: []string{"c", "d", "a", "e"}},
+		{deleted: true, want: []string{"b"}},
+	}
+	for _, tt := range tests {
+		filter := &models.ImageFilter{SortBy: models.SortByFileName, Order: models.OrderAsc, Deleted: tt.deleted}
+		list, err := repo.Filter(ctx, filter)
+		if err != nil {
+			t.Fatalf("Filter(deleted=%v) error = %v", tt.deleted, err)
+		}
+		if got := imageIDs(list.Images); !slices.Equal(got, tt.want) {
+			t.Errorf("Filter(deleted=%v) = %v, want %v", tt.deleted, got, tt.want)
+		}
+	}
+}
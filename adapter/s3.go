@@ -0,0 +1,120 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3ObjectStore is an ObjectStore backed by a bucket of an S3-compatible
+// service such as MinIO. Object versions are S3 entity tags, which reads
+// require to match.
+type S3ObjectStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3ObjectStore connects to the S3 endpoint (host[:port]) with static credentials.
+func NewS3ObjectStore(endpoint, region, accessKeyID, secretAccessKey, bucketName string, useSSL bool) (*S3ObjectStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	return &S3ObjectStore{
+		client: client,
+		bucket: bucketName,
+	}, nil
+}
+
+func (s *S3ObjectStore) Stat(ctx context.Context, name string) (*repository.ObjectAttrs, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object attributes: %w", translateError(err))
+	}
+	return s3Attrs(info), nil
+}
+
+func (s *S3ObjectStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.OpenRange(ctx, name, "", 0, -1)
+}
+
+func (s *S3ObjectStore) OpenRange(ctx context.Context, name, version string, offset, length int64) (io.ReadCloser, error) {
+	var opts minio.GetObjectOptions
+	if version != "" {
+		if err := opts.SetMatchETag(version); err != nil {
+			return nil, fmt.Errorf("failed to open object: %w", err)
+		}
+	}
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if offset > 0 || length > 0 {
+		// An end of 0 with a positive start reads to the end of the object.
+		end := int64(0)
+		if length > 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, fmt.Errorf("failed to open object: %w", err)
+		}
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", translateError(err))
+	}
+	// GetObject is lazy; stat it so that missing objects fail here.
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to open object: %w", translateError(err))
+	}
+	return object, nil
+}
+
+func (s *S3ObjectStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", translateError(info.Err))
+		}
+		names = append(names, info.Key)
+	}
+	return names, nil
+}
+
+func (s *S3ObjectStore) Delete(ctx context.Context, name string) error {
+	// S3 reports success for objects that do not exist.
+	if err := s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *S3ObjectStore) Write(ctx context.Context, name, contentType string, r io.Reader) error {
+	_, err := s.client.PutObject(ctx, s.bucket, name, r, -1, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to write object: %w", translateError(err))
+	}
+	return nil
+}
+
+func s3Attrs(info minio.ObjectInfo) *repository.ObjectAttrs {
+	etag := strings.Trim(info.ETag, `"`)
+	return &repository.ObjectAttrs{
+		Name:         info.Key,
+		ContentType:  info.ContentType,
+		Size:         info.Size,
+		ETag:         `"` + etag + `"`,
+		LastModified: info.LastModified,
+		Version:      etag,
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/manifest"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/requestid"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+const importUsage = `Usage: image-catalog-service import [flags] <manifest|->
+
+Sets image labels from a CSV or JSON Lines manifest and writes a CSV report
+with one line per manifest row. Exits with 1 if any row failed.
+
+Flags:
+`
+
+// runImport runs the import subcommand with the given arguments and returns
+// the exit code. The import runs with global admin rights on behalf of the
+// -actor, who is recorded in the audit log.
+func runImport(ctx context.Context, imageService *service.ImageService, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), importUsage)
+		flags.PrintDefaults()
+	}
+	matchBy := flags.String("match", models.ImportMatchFileUID, "match rows to images by file_uid or file_name")
+	format := flags.String("format", "", "manifest format, csv or jsonl (default from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "report the changes without writing them")
+	actor := flags.String("actor", os.Getenv("USER"), "user recorded as the author of the changes")
+	reportPath := flags.String("report", "", "write the report to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *actor == "" {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = manifest.FormatCSV
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".ndjson":
+			*format = manifest.FormatJSONL
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		in = file
+	}
+	rows, err := manifest.Read(in, *format, *matchBy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to read manifest: %v\n", err)
+		return 1
+	}
+
+	requestID := requestid.New()
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{UserID: *actor, Role: auth.RoleAdmin})
+	ctx = requestid.WithID(ctx, requestID)
+	report, err := imageService.ImportLabels(ctx, &models.ImportRequest{MatchBy: *matchBy, DryRun: *dryRun, Rows: rows})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Import failed: %v\n", err)
+		return 1
+	}
+
+	out := os.Stdout
+	if *reportPath != "" {
+		if out, err = os.Create(*reportPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	if err := writeImportReport(out, report); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write report: %v\n", err)
+		return 1
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Checked"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d rows: %d updated, %d unchanged, %d failed (request ID %s)\n",
+		verb, report.Rows, report.Updated, report.Unchanged, report.Failed, requestID)
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writeImportReport writes the report as CSV. Changes are listed as
+// "field: old -> new", separated by semicolons.
+func writeImportReport(w io.Writer, report *models.ImportReport) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"line", "key", "image_id", "status", "changes", "error"})
+	for _, result := range report.Results {
+		changes := make([]string, len(result.Changes))
+		for i, change := range result.Changes {
+			changes[i] = fmt.Sprintf("%s: %s -> %s", change.Field, labelValue(change.OldValue), labelValue(change.NewValue))
+		}
+		_ = out.Write([]string{strconv.Itoa(result.Line), result.Key, result.ImageID, result.Status, strings.Join(changes, "; "), result.Error})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func labelValue(value *string) string {
+	if value == nil {
+		return "(none)"
+	}
+	return strconv.Quote(*value)
+}
@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/storage"
 	firebase "firebase.google.com/go"
 
 	"github.com/histopathai/image-catalog-service/adapter"
 	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/auth"
 	"github.com/histopathai/image-catalog-service/internal/handlers"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
 	"github.com/histopathai/image-catalog-service/internal/service"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
 	"github.com/histopathai/image-catalog-service/server"
 )
 
@@ -24,32 +30,75 @@ func main() {
 		log.Fatalf("❌ Failed to load config: %v", err)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// Subcommands share the service setup but keep stdout for their output.
+	command := ""
+	logOutput := os.Stdout
+	if len(os.Args) > 1 && (os.Args[1] == "import" || os.Args[1] == "migrate-subtype") {
+		command, logOutput = os.Args[1], os.Stderr
+	}
+
+	logger := slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 
 	slog.SetDefault(logger)
 
 	// Initialize context
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	fmt.Printf("Loaded configuration: %+v\n", cfg)
+	if command == "" {
+		fmt.Printf("Loaded configuration: %+v\n", cfg)
+	}
 
-	// Initialize Firestore
-	firestoreClient, err := initFireStore(ctx, cfg)
+	// Initialize the repositories
+	repos, err := initRepositories(ctx, cfg)
 	if err != nil {
-		slog.Error("Failed to initialize Firestore", "error", err)
+		slog.Error("Failed to initialize repositories", "error", err)
 		os.Exit(1)
 	}
 
-	// Initialize ImageService
-	imageService, err := initImageService(firestoreClient, cfg)
+	if command == "migrate-subtype" {
+		code := runMigrateSubTypes(ctx, repos.images)
+		cancel()
+		os.Exit(code)
+	}
+
+	// Initialize the object store holding image assets
+	objectStore, err := initObjectStore(ctx, cfg)
+	if err != nil {
+		slog.Error("Failed to initialize object store", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize services
+	accessService := service.NewAccessService(repos.acl)
+	auditService := service.NewAuditService(repos.audit, repos.images, accessService)
+	taxonomyService := service.NewTaxonomyService(repos.taxonomies)
+
+	// Initialize the cache of proxied tiles
+	tileCache, err := initTileCache(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize tile cache", "error", err)
+		os.Exit(1)
+	}
+
+	imageService, err := initImageService(repos.images, repos.annotations, auditService, objectStore, accessService, taxonomyService, tileCache, cfg)
 
 	if err != nil {
 		slog.Error("Failed to initialize ImageService", "error", err)
 		os.Exit(1)
 	}
 
+	if command == "import" {
+		code := runImport(ctx, imageService, os.Args[2:])
+		cancel()
+		os.Exit(code)
+	}
+
+	// Start purging expired images from the trash
+	service.NewPurger(imageService, cfg.Trash.Retention, cfg.Trash.PurgeInterval).Start(ctx)
+
 	// Initialize Handlers
 	imageHandler := handlers.NewImageHandler(imageService)
 	if imageHandler == nil {
@@ -57,14 +106,32 @@ func main() {
 		os.Exit(1)
 	}
 
-	gcsProxyHandler, err := handlers.NewGCSProxyHandler(cfg.ProjectID, cfg.BucketName)
+	aclHandler := handlers.NewACLHandler(accessService)
+
+	annotationService := service.NewAnnotationService(repos.annotations, repos.images, accessService)
+	annotationHandler := handlers.NewAnnotationHandler(annotationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyService)
+	exportHandler := handlers.NewExportHandler(imageService, cfg)
+
+	gcsProxyHandler := handlers.NewGCSProxyHandler(objectStore, imageService, tileCache, cfg.Proxy)
+
+	tileRenderer := service.NewTileRenderer(objectStore, tileCache, cfg.Render)
+	iiifHandler := handlers.NewIIIFHandler(imageService, tileRenderer, cfg)
+	renderHandler := handlers.NewRenderHandler(imageService, tileRenderer, cfg)
+
+	// Initialize the request authenticator
+	authenticator, err := auth.NewAuthenticator(cfg.Auth)
 	if err != nil {
-		slog.Error("Failed to create GCSProxyHandler", "error", err)
+		slog.Error("Failed to create authenticator", "error", err)
 		os.Exit(1)
 	}
+	if cfg.Auth.Mode == config.AuthModeHeader {
+		slog.Warn("Trusting X-User-* headers for authentication; the service must only be reachable through the gateway")
+	}
 
 	// Initialize Server
-	server := server.NewServer(cfg, imageHandler, gcsProxyHandler)
+	server := server.NewServer(cfg, imageHandler, aclHandler, annotationHandler, auditHandler, taxonomyHandler, exportHandler, gcsProxyHandler, iiifHandler, renderHandler, authenticator)
 
 	if server == nil {
 		slog.Error("Failed to create Server")
@@ -80,17 +147,129 @@ func main() {
 	slog.Info("Image processing result subscriber started")
 }
 
-func initImageService(firestoreClient *firestore.Client, cfg *config.Config) (*service.ImageService, error) {
-	if firestoreClient == nil {
-		return nil, fmt.Errorf("firestore client is nil")
+// repositories groups the persistence backends selected by the config.
+type repositories struct {
+	images      repository.ImageRepository
+	acl         repository.ACLRepository
+	annotations repository.AnnotationRepository
+	audit       repository.AuditRepository
+	taxonomies  repository.TaxonomyRepository
+}
+
+func initRepositories(ctx context.Context, cfg *config.Config) (*repositories, error) {
+	switch cfg.Repository.Backend {
+	case "memory":
+		images, err := loadSeedImages(cfg.Repository.SeedFile)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Using in-memory repositories", "seeded_images", len(images))
+		auditRepo := adapter.NewMemoryAuditRepository()
+		return &repositories{
+			images:      adapter.NewMemoryImageRepository(auditRepo, images...),
+			acl:         adapter.NewMemoryACLRepository(),
+			annotations: adapter.NewMemoryAnnotationRepository(),
+			audit:       auditRepo,
+			taxonomies:  adapter.NewMemoryTaxonomyRepository(),
+		}, nil
+	default:
+		firestoreClient, err := initFireStore(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Firestore: %w", err)
+		}
+
+		imageRepo, err := adapter.NewFirestoreCollection(firestoreClient, "images", "audit_log", cfg.BucketName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Firestore repository: %w", err)
+		}
+		aclRepo, err := adapter.NewFirestoreACLRepository(firestoreClient, "dataset_grants")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Firestore ACL repository: %w", err)
+		}
+		annotationRepo, err := adapter.NewFirestoreAnnotationRepository(firestoreClient, "annotations")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Firestore annotation repository: %w", err)
+		}
+		auditRepo, err := adapter.NewFirestoreAuditRepository(firestoreClient, "audit_log")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Firestore audit repository: %w", err)
+		}
+		taxonomyRepo, err := adapter.NewFirestoreTaxonomyRepository(firestoreClient, "taxonomies")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Firestore taxonomy repository: %w", err)
+		}
+		return &repositories{
+			images:      imageRepo,
+			acl:         aclRepo,
+			annotations: annotationRepo,
+			audit:       auditRepo,
+			taxonomies:  taxonomyRepo,
+		}, nil
+	}
+}
+
+// loadSeedImages reads a JSON array of images used to preload the in-memory repository.
+func loadSeedImages(path string) ([]*models.Image, error) {
+	if path == "" {
+		return nil, nil
 	}
 
-	repo, err := adapter.NewFirestoreCollection(firestoreClient, "images")
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Firestore repository: %w", err)
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	var images []*models.Image
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, fmt.Errorf("failed to parse seed file: %w", err)
+	}
+	return images, nil
+}
+
+func initObjectStore(ctx context.Context, cfg *config.Config) (repository.ObjectStore, error) {
+	switch cfg.Storage.Backend {
+	case "local":
+		slog.Info("Using local object store", "root", cfg.Storage.LocalRoot)
+		return adapter.NewLocalObjectStore(cfg.Storage.LocalRoot)
+	case "s3":
+		s3 := cfg.Storage.S3
+		slog.Info("Using S3 object store", "endpoint", s3.Endpoint, "bucket", s3.Bucket)
+		return adapter.NewS3ObjectStore(s3.Endpoint, s3.Region, s3.AccessKeyID, s3.SecretAccessKey, s3.Bucket, s3.UseSSL)
+	default:
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		return adapter.NewGCSObjectStore(client, cfg.BucketName), nil
+	}
+}
+
+// initTileCache builds the tile cache from the configured memory and disk tiers.
+func initTileCache(cfg *config.Config) (*tilecache.Cache, error) {
+	var tiers []tilecache.Tier
+	if cfg.TileCache.MemoryBytes > 0 {
+		tiers = append(tiers, tilecache.NewMemoryTier(cfg.TileCache.MemoryBytes))
+	}
+	if cfg.TileCache.DiskDir != "" {
+		disk, err := tilecache.NewDiskTier(cfg.TileCache.DiskDir, cfg.TileCache.DiskBytes)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, disk)
+	}
+	slog.Info("Using tile cache", "tiers", len(tiers), "memory_bytes", cfg.TileCache.MemoryBytes, "disk_dir", cfg.TileCache.DiskDir)
+	return tilecache.New(cfg.TileCache.TTL, cfg.TileCache.RevalidateAfter, cfg.TileCache.MaxObjectBytes, tiers...), nil
+}
+
+func initImageService(repo repository.ImageRepository, annotations repository.AnnotationRepository, audit *service.AuditService, store repository.ObjectStore, access *service.AccessService, taxonomies *service.TaxonomyService, tiles *tilecache.Cache, cfg *config.Config) (*service.ImageService, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("image repository is nil")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("object store is nil")
 	}
 
-	imageService := service.NewImageService(repo, cfg)
+	imageService := service.NewImageService(repo, annotations, audit, store, access, taxonomies, tiles, cfg)
 	if imageService == nil {
 		return nil, fmt.Errorf("failed to create ImageService")
 	}
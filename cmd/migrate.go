@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// subTypeMigrator is implemented by repositories that may hold subtypes
+// under the legacy subtype field.
+type subTypeMigrator interface {
+	MigrateSubTypes(ctx context.Context) (int, error)
+}
+
+// runMigrateSubTypes runs the migrate-subtype subcommand, which moves
+// subtypes written by older label updates to the sub_type field, and returns
+// the exit code.
+func runMigrateSubTypes(ctx context.Context, images repository.ImageRepository) int {
+	migrator, ok := images.(subTypeMigrator)
+	if !ok {
+		fmt.Println("The repository has no legacy subtypes to migrate")
+		return 0
+	}
+	moved, err := migrator.MigrateSubTypes(ctx)
+	fmt.Printf("Moved the subtype of %d images to sub_type\n", moved)
+	if err != nil {
+		slog.Error("Failed to migrate subtypes", "error", err)
+		return 1
+	}
+	return 0
+}
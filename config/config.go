@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +16,13 @@ type Config struct {
 	Region     string
 	BucketName string
 	Server     ServerConfig
+	Repository RepositoryConfig
+	Storage    StorageConfig
+	Trash      TrashConfig
+	Auth       AuthConfig
+	Proxy      ProxyConfig
+	TileCache  TileCacheConfig
+	Render     RenderConfig
 }
 
 type ServerConfig struct {
@@ -24,6 +33,108 @@ type ServerConfig struct {
 	GINMode      string
 }
 
+type RepositoryConfig struct {
+	Backend  string // "firestore" or "memory"
+	SeedFile string // Optional JSON file with images to preload into the memory backend
+}
+
+type StorageConfig struct {
+	Backend   string // "gcs", "local" or "s3"
+	LocalRoot string // Root directory of the local backend
+	S3        S3Config
+
+	// Asset deletion
+	DeleteConcurrency int
+	DeleteMaxAttempts int
+	DeleteBackoff     time.Duration
+}
+
+// S3Config locates the bucket of the S3-compatible backend, e.g. MinIO.
+type S3Config struct {
+	Endpoint        string // host[:port]
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// String hides the secret key when the configuration is logged.
+func (c S3Config) String() string {
+	secret := ""
+	if c.SecretAccessKey != "" {
+		secret = "[redacted]"
+	}
+	return fmt.Sprintf("{Endpoint:%s Region:%s Bucket:%s AccessKeyID:%s SecretAccessKey:%s UseSSL:%t}",
+		c.Endpoint, c.Region, c.Bucket, c.AccessKeyID, secret, c.UseSSL)
+}
+
+type TrashConfig struct {
+	Retention     time.Duration // How long deleted images stay restorable
+	PurgeInterval time.Duration // How often expired images are purged
+}
+
+const (
+	AuthModeJWT    = "jwt"    // Verify signed bearer tokens
+	AuthModeHeader = "header" // Trust X-User-* headers set by the API gateway
+)
+
+type AuthConfig struct {
+	Mode          string
+	JWKSFile      string // JSON Web Key Set with the token verification keys
+	PublicKeyFile string // PEM encoded RSA or ECDSA public key
+	Issuer        string // Expected "iss" claim, if set
+	Audience      string // Expected "aud" claim, if set
+	Leeway        time.Duration
+}
+
+type ProxyConfig struct {
+	// Object prefixes that any authenticated caller may read through the
+	// proxy, in addition to the assets of catalogued images.
+	AllowedPrefixes []string
+
+	// Cache-Control values per object kind. Tiles never change once written;
+	// DZI descriptors and thumbnails are rewritten when an image is re-processed.
+	CacheControlTile      string
+	CacheControlDZI       string
+	CacheControlThumbnail string
+	CacheControlDefault   string
+
+	// Signed URLs let clients without credentials read image assets until
+	// they expire. They are disabled unless a signing key is set.
+	SigningKey    string
+	SignedURLTTL  time.Duration
+	PublicBaseURL string // Prefix of signed URLs and IIIF ids, e.g. "https://catalog.example.org"; relative or the request's Host if empty
+}
+
+// String hides the signing key when the configuration is logged.
+func (c ProxyConfig) String() string {
+	key := ""
+	if c.SigningKey != "" {
+		key = "[redacted]"
+	}
+	return fmt.Sprintf("{AllowedPrefixes:%v CacheControlTile:%s CacheControlDZI:%s CacheControlThumbnail:%s CacheControlDefault:%s SigningKey:%s SignedURLTTL:%s PublicBaseURL:%s}",
+		c.AllowedPrefixes, c.CacheControlTile, c.CacheControlDZI, c.CacheControlThumbnail, c.CacheControlDefault, key, c.SignedURLTTL, c.PublicBaseURL)
+}
+
+type TileCacheConfig struct {
+	MemoryBytes     int64         // Capacity of the in-memory tier, 0 to disable it
+	DiskDir         string        // Directory of the on-disk tier, empty to disable it
+	DiskBytes       int64         // Capacity of the on-disk tier
+	MaxObjectBytes  int64         // Larger objects are streamed without caching
+	TTL             time.Duration // How long entries are served before reloading, 0 for no limit
+	RevalidateAfter time.Duration // How long DZI descriptors and thumbnails are served before they are checked against storage, 0 to never check
+}
+
+// RenderConfig bounds the images composed from tile pyramids.
+type RenderConfig struct {
+	MaxWidth        int // Largest rendered image width in pixels
+	MaxHeight       int // Largest rendered image height in pixels
+	MaxPixels       int // Largest rendered region area in pixels
+	JPEGQuality     int
+	TileConcurrency int // Tiles read in parallel per rendered image
+}
+
 func LoadConfig() (*Config, error) {
 	env := os.Getenv("ENV")
 
@@ -59,15 +170,132 @@ func LoadConfig() (*Config, error) {
 	}
 
 	bucketName := os.Getenv("GCS_BUCKET_NAME")
-	if bucketName == "" {
-		return nil, fmt.Errorf("GCS_BUCKET_NAME environment variable is not set")
-	}
 
 	readTimeout, _ := time.ParseDuration(getEnvOrDefault("READ_TIMEOUT", "15m"))
 	writeTimeout, _ := time.ParseDuration(getEnvOrDefault("WRITE_TIMEOUT", "60s"))
 	idleTimeout, _ := time.ParseDuration(getEnvOrDefault("IDLE_TIMEOUT", "5m"))
 	ginMode := getEnvOrDefault("GIN_MODE", "release")
 
+	repositoryBackend := getEnvOrDefault("REPOSITORY_BACKEND", "firestore")
+	if repositoryBackend != "firestore" && repositoryBackend != "memory" {
+		return nil, fmt.Errorf("REPOSITORY_BACKEND must be either \"firestore\" or \"memory\", got %q", repositoryBackend)
+	}
+
+	storageBackend := getEnvOrDefault("STORAGE_BACKEND", "gcs")
+	localRoot := os.Getenv("STORAGE_LOCAL_ROOT")
+	s3UseSSL, err := strconv.ParseBool(getEnvOrDefault("S3_USE_SSL", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_USE_SSL: %w", err)
+	}
+	s3Config := S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		UseSSL:          s3UseSSL,
+	}
+	switch storageBackend {
+	case "gcs":
+		if bucketName == "" {
+			return nil, fmt.Errorf("GCS_BUCKET_NAME environment variable is not set")
+		}
+	case "local":
+		if localRoot == "" {
+			return nil, fmt.Errorf("STORAGE_LOCAL_ROOT is required when STORAGE_BACKEND is \"local\"")
+		}
+	case "s3":
+		if s3Config.Endpoint == "" || s3Config.Bucket == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required when STORAGE_BACKEND is \"s3\"")
+		}
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND must be \"gcs\", \"local\" or \"s3\", got %q", storageBackend)
+	}
+
+	deleteConcurrency, err := strconv.Atoi(getEnvOrDefault("ASSET_DELETE_CONCURRENCY", "16"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ASSET_DELETE_CONCURRENCY: %w", err)
+	}
+	deleteMaxAttempts, err := strconv.Atoi(getEnvOrDefault("ASSET_DELETE_MAX_ATTEMPTS", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ASSET_DELETE_MAX_ATTEMPTS: %w", err)
+	}
+	deleteBackoff, _ := time.ParseDuration(getEnvOrDefault("ASSET_DELETE_BACKOFF", "200ms"))
+
+	trashRetention, err := time.ParseDuration(getEnvOrDefault("TRASH_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRASH_RETENTION: %w", err)
+	}
+	purgeInterval, err := time.ParseDuration(getEnvOrDefault("PURGE_INTERVAL", "1h"))
+	if err != nil || purgeInterval <= 0 {
+		return nil, fmt.Errorf("PURGE_INTERVAL must be a positive duration")
+	}
+
+	authMode := getEnvOrDefault("AUTH_MODE", AuthModeJWT)
+	jwksFile := os.Getenv("AUTH_JWKS_FILE")
+	publicKeyFile := os.Getenv("AUTH_PUBLIC_KEY_FILE")
+	switch authMode {
+	case AuthModeJWT:
+		if jwksFile == "" && publicKeyFile == "" {
+			return nil, fmt.Errorf("AUTH_JWKS_FILE or AUTH_PUBLIC_KEY_FILE is required when AUTH_MODE is \"jwt\"")
+		}
+	case AuthModeHeader:
+	default:
+		return nil, fmt.Errorf("AUTH_MODE must be either \"jwt\" or \"header\", got %q", authMode)
+	}
+	authLeeway, _ := time.ParseDuration(getEnvOrDefault("AUTH_LEEWAY", "1m"))
+
+	proxySigningKey := os.Getenv("PROXY_SIGNING_KEY")
+	if proxySigningKey != "" && len(proxySigningKey) < 32 {
+		return nil, fmt.Errorf("PROXY_SIGNING_KEY must be at least 32 bytes long")
+	}
+	signedURLTTL, err := time.ParseDuration(getEnvOrDefault("PROXY_SIGNED_URL_TTL", "24h"))
+	if err != nil || signedURLTTL <= 0 {
+		return nil, fmt.Errorf("PROXY_SIGNED_URL_TTL must be a positive duration")
+	}
+
+	tileCacheMemoryBytes, err := strconv.ParseInt(getEnvOrDefault("TILE_CACHE_MEMORY_BYTES", "268435456"), 10, 64)
+	if err != nil || tileCacheMemoryBytes < 0 {
+		return nil, fmt.Errorf("TILE_CACHE_MEMORY_BYTES must be a non-negative integer")
+	}
+	tileCacheDiskBytes, err := strconv.ParseInt(getEnvOrDefault("TILE_CACHE_DISK_BYTES", "1073741824"), 10, 64)
+	if err != nil || tileCacheDiskBytes <= 0 {
+		return nil, fmt.Errorf("TILE_CACHE_DISK_BYTES must be a positive integer")
+	}
+	tileCacheMaxObjectBytes, err := strconv.ParseInt(getEnvOrDefault("TILE_CACHE_MAX_OBJECT_BYTES", "1048576"), 10, 64)
+	if err != nil || tileCacheMaxObjectBytes < 0 {
+		return nil, fmt.Errorf("TILE_CACHE_MAX_OBJECT_BYTES must be a non-negative integer")
+	}
+	tileCacheTTL, err := time.ParseDuration(getEnvOrDefault("TILE_CACHE_TTL", "24h"))
+	if err != nil || tileCacheTTL < 0 {
+		return nil, fmt.Errorf("TILE_CACHE_TTL must be a non-negative duration")
+	}
+	tileCacheRevalidateAfter, err := time.ParseDuration(getEnvOrDefault("TILE_CACHE_REVALIDATE_AFTER", "1m"))
+	if err != nil || tileCacheRevalidateAfter < 0 {
+		return nil, fmt.Errorf("TILE_CACHE_REVALIDATE_AFTER must be a non-negative duration")
+	}
+
+	renderMaxWidth, err := strconv.Atoi(getEnvOrDefault("RENDER_MAX_WIDTH", "4096"))
+	if err != nil || renderMaxWidth <= 0 {
+		return nil, fmt.Errorf("RENDER_MAX_WIDTH must be a positive integer")
+	}
+	renderMaxHeight, err := strconv.Atoi(getEnvOrDefault("RENDER_MAX_HEIGHT", "4096"))
+	if err != nil || renderMaxHeight <= 0 {
+		return nil, fmt.Errorf("RENDER_MAX_HEIGHT must be a positive integer")
+	}
+	renderMaxPixels, err := strconv.Atoi(getEnvOrDefault("RENDER_MAX_PIXELS", "8388608"))
+	if err != nil || renderMaxPixels <= 0 {
+		return nil, fmt.Errorf("RENDER_MAX_PIXELS must be a positive integer")
+	}
+	renderJPEGQuality, err := strconv.Atoi(getEnvOrDefault("RENDER_JPEG_QUALITY", "90"))
+	if err != nil || renderJPEGQuality < 1 || renderJPEGQuality > 100 {
+		return nil, fmt.Errorf("RENDER_JPEG_QUALITY must be between 1 and 100")
+	}
+	renderTileConcurrency, err := strconv.Atoi(getEnvOrDefault("RENDER_TILE_CONCURRENCY", "8"))
+	if err != nil || renderTileConcurrency <= 0 {
+		return nil, fmt.Errorf("RENDER_TILE_CONCURRENCY must be a positive integer")
+	}
+
 	return &Config{
 		ProjectID:  projectID,
 		Region:     region,
@@ -79,6 +307,55 @@ func LoadConfig() (*Config, error) {
 			IdleTimeout:  idleTimeout,
 			GINMode:      ginMode,
 		},
+		Repository: RepositoryConfig{
+			Backend:  repositoryBackend,
+			SeedFile: os.Getenv("MEMORY_SEED_FILE"),
+		},
+		Storage: StorageConfig{
+			Backend:           storageBackend,
+			LocalRoot:         localRoot,
+			S3:                s3Config,
+			DeleteConcurrency: deleteConcurrency,
+			DeleteMaxAttempts: deleteMaxAttempts,
+			DeleteBackoff:     deleteBackoff,
+		},
+		Trash: TrashConfig{
+			Retention:     trashRetention,
+			PurgeInterval: purgeInterval,
+		},
+		Auth: AuthConfig{
+			Mode:          authMode,
+			JWKSFile:      jwksFile,
+			PublicKeyFile: publicKeyFile,
+			Issuer:        os.Getenv("AUTH_ISSUER"),
+			Audience:      os.Getenv("AUTH_AUDIENCE"),
+			Leeway:        authLeeway,
+		},
+		Proxy: ProxyConfig{
+			AllowedPrefixes:       splitList(os.Getenv("PROXY_ALLOWED_PREFIXES")),
+			CacheControlTile:      getEnvOrDefault("CACHE_CONTROL_TILE", "private, max-age=31536000, immutable"),
+			CacheControlDZI:       getEnvOrDefault("CACHE_CONTROL_DZI", "private, no-cache"),
+			CacheControlThumbnail: getEnvOrDefault("CACHE_CONTROL_THUMBNAIL", "private, no-cache"),
+			CacheControlDefault:   getEnvOrDefault("CACHE_CONTROL_DEFAULT", "private, no-cache"),
+			SigningKey:            proxySigningKey,
+			SignedURLTTL:          signedURLTTL,
+			PublicBaseURL:         strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		},
+		TileCache: TileCacheConfig{
+			MemoryBytes:     tileCacheMemoryBytes,
+			DiskDir:         os.Getenv("TILE_CACHE_DISK_DIR"),
+			DiskBytes:       tileCacheDiskBytes,
+			MaxObjectBytes:  tileCacheMaxObjectBytes,
+			TTL:             tileCacheTTL,
+			RevalidateAfter: tileCacheRevalidateAfter,
+		},
+		Render: RenderConfig{
+			MaxWidth:        renderMaxWidth,
+			MaxHeight:       renderMaxHeight,
+			MaxPixels:       renderMaxPixels,
+			JPEGQuality:     renderJPEGQuality,
+			TileConcurrency: renderTileConcurrency,
+		},
 	}, nil
 }
 
@@ -88,3 +365,14 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
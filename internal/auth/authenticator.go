@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/histopathai/image-catalog-service/config"
+)
+
+// ErrUnauthenticated is returned when a request carries no valid credentials.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Authenticator extracts the principal from an incoming request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// NewAuthenticator builds the authenticator selected by the auth config.
+func NewAuthenticator(cfg config.AuthConfig) (Authenticator, error) {
+	switch cfg.Mode {
+	case config.AuthModeHeader:
+		return &HeaderAuthenticator{}, nil
+	case config.AuthModeJWT:
+		return NewJWTAuthenticator(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported auth mode %q", cfg.Mode)
+	}
+}
+
+// HeaderAuthenticator trusts the X-User-* headers set by the API gateway.
+// It must only be used when the service is not reachable except through the gateway.
+type HeaderAuthenticator struct{}
+
+func (a *HeaderAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		return nil, fmt.Errorf("%w: X-User-ID header is missing", ErrUnauthenticated)
+	}
+
+	principal := &Principal{
+		UserID: userID,
+		Role:   r.Header.Get("X-User-Role"),
+	}
+	for _, group := range strings.Split(r.Header.Get("X-User-Groups"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			principal.Groups = append(principal.Groups, group)
+		}
+	}
+	return principal, nil
+}
+
+// JWTAuthenticator verifies RS256/ES256 signed bearer tokens against a JWKS
+// file or a single PEM public key.
+type JWTAuthenticator struct {
+	keys     jose.JSONWebKeySet
+	expected jwt.Expected
+	leeway   time.Duration
+}
+
+type tokenClaims struct {
+	jwt.Claims
+	Role   string   `json:"role"`
+	Groups []string `json:"groups"`
+}
+
+var signatureAlgorithms = []jose.SignatureAlgorithm{jose.RS256, jose.ES256}
+
+func NewJWTAuthenticator(cfg config.AuthConfig) (*JWTAuthenticator, error) {
+	keys, err := loadKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := jwt.Expected{Issuer: cfg.Issuer}
+	if cfg.Audience != "" {
+		expected.AnyAudience = jwt.Audience{cfg.Audience}
+	}
+
+	return &JWTAuthenticator{
+		keys:     keys,
+		expected: expected,
+		leeway:   cfg.Leeway,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || raw == "" {
+		return nil, fmt.Errorf("%w: bearer token is missing", ErrUnauthenticated)
+	}
+
+	token, err := jwt.ParseSigned(raw, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrUnauthenticated)
+	}
+
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: token has no expiry", ErrUnauthenticated)
+	}
+	if err := claims.ValidateWithLeeway(a.expected.WithTime(time.Now()), a.leeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	return &Principal{
+		UserID: claims.Subject,
+		Role:   claims.Role,
+		Groups: claims.Groups,
+	}, nil
+}
+
+// verify checks the token signature with the key named by its kid header,
+// or with every configured key if the token has none.
+func (a *JWTAuthenticator) verify(token *jwt.JSONWebToken) (*tokenClaims, error) {
+	candidates := a.keys.Keys
+	if len(token.Headers) > 0 && token.Headers[0].KeyID != "" {
+		candidates = a.keys.Key(token.Headers[0].KeyID)
+	}
+
+	for _, key := range candidates {
+		var claims tokenClaims
+		if err := token.Claims(key.Key, &claims); err == nil {
+			return &claims, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: invalid token signature", ErrUnauthenticated)
+}
+
+func loadKeys(cfg config.AuthConfig) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return keys, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return keys, fmt.Errorf("failed to parse JWKS file: %w", err)
+		}
+	}
+
+	if cfg.PublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return keys, fmt.Errorf("failed to read public key file: %w", err)
+		}
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return keys, err
+		}
+		keys.Keys = append(keys.Keys, jose.JSONWebKey{Key: key})
+	}
+
+	for _, key := range keys.Keys {
+		if !key.IsPublic() {
+			return keys, fmt.Errorf("verification keys must be public keys")
+		}
+	}
+	if len(keys.Keys) == 0 {
+		return keys, fmt.Errorf("no token verification keys configured")
+	}
+	return keys, nil
+}
+
+func parsePublicKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key file is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
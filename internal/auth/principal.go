@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Roles understood by the catalog.
+const (
+	RoleAdmin     = "admin"
+	RoleAnnotator = "annotator"
+	RoleViewer    = "viewer"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string   `json:"user_id"`
+	Role   string   `json:"role"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// HasRole reports whether the principal has one of the given roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	return p != nil && slices.Contains(roles, p.Role)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
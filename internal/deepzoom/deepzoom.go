@@ -0,0 +1,185 @@
+// Package deepzoom describes Deep Zoom image pyramids: the levels, tiles and
+// descriptor of an image tiled by the processing pipeline.
+package deepzoom
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// Defaults used by the pipeline for images that do not record their own.
+const (
+	DefaultTileSize = 254
+	DefaultOverlap  = 1
+	DefaultFormat   = "jpeg"
+
+	namespace = "http://schemas.microsoft.com/deepzoom/2008"
+)
+
+// Pyramid is the geometry of a Deep Zoom image. Level MaxLevel is the full
+// resolution image and each lower level halves it, rounding up, down to a
+// single pixel at level 0.
+type Pyramid struct {
+	Width    int
+	Height   int
+	TileSize int
+	Overlap  int
+	Format   string // Tile file extension, e.g. "jpeg"
+}
+
+// ForImage returns the pyramid of a catalogued image, filling in the
+// defaults for tile size, overlap and format.
+func ForImage(img *models.Image) (*Pyramid, error) {
+	if img.Width <= 0 || img.Height <= 0 {
+		return nil, models.NewError(models.ErrConflict, "image %s has no recorded width and height", img.ID)
+	}
+
+	p := &Pyramid{
+		Width:    img.Width,
+		Height:   img.Height,
+		TileSize: DefaultTileSize,
+		Overlap:  DefaultOverlap,
+		Format:   TileFormat(img.Format),
+	}
+	if img.TileSize > 0 {
+		p.TileSize = img.TileSize
+	}
+	if img.Overlap != nil {
+		p.Overlap = *img.Overlap
+	}
+	return p, nil
+}
+
+// TileFormat maps an image format to the extension of its tiles. Source
+// formats that are not tile formats, such as "svs", map to DefaultFormat.
+func TileFormat(format string) string {
+	switch format = strings.ToLower(strings.TrimPrefix(format, ".")); format {
+	case "jpg", "jpeg":
+		return "jpeg"
+	case "png", "webp":
+		return format
+	default:
+		return DefaultFormat
+	}
+}
+
+// MaxLevel returns the level holding the full resolution image.
+func (p *Pyramid) MaxLevel() int {
+	return bits.Len(uint(max(p.Width, p.Height) - 1))
+}
+
+// LevelSize returns the dimensions of the image at a level.
+func (p *Pyramid) LevelSize(level int) (width, height int) {
+	shift := p.MaxLevel() - level
+	return ceilShift(p.Width, shift), ceilShift(p.Height, shift)
+}
+
+// Scale returns the factor by which a level is reduced from full resolution.
+func (p *Pyramid) Scale(level int) int {
+	return 1 << (p.MaxLevel() - level)
+}
+
+// TileCount returns the number of tile columns and rows of a level.
+func (p *Pyramid) TileCount(level int) (columns, rows int) {
+	width, height := p.LevelSize(level)
+	return ceilDiv(width, p.TileSize), ceilDiv(height, p.TileSize)
+}
+
+// TileBounds returns the pixels a tile covers in level coordinates,
+// including its overlap with the neighbouring tiles.
+func (p *Pyramid) TileBounds(level, column, row int) image.Rectangle {
+	width, height := p.LevelSize(level)
+	x := column * p.TileSize
+	y := row * p.TileSize
+	return image.Rect(
+		max(x-p.Overlap, 0),
+		max(y-p.Overlap, 0),
+		min(x+p.TileSize+p.Overlap, width),
+		min(y+p.TileSize+p.Overlap, height),
+	)
+}
+
+// TileArea returns the pixels a tile covers in level coordinates without
+// its overlap, which no other tile of the level covers.
+func (p *Pyramid) TileArea(level, column, row int) image.Rectangle {
+	width, height := p.LevelSize(level)
+	x := column * p.TileSize
+	y := row * p.TileSize
+	return image.Rect(x, y, min(x+p.TileSize, width), min(y+p.TileSize, height))
+}
+
+// TileName returns the path of a tile relative to the tiles directory.
+func (p *Pyramid) TileName(level, column, row int) string {
+	return fmt.Sprintf("%d/%d_%d.%s", level, column, row, p.Format)
+}
+
+// ValidLevel reports whether the pyramid has the level.
+func (p *Pyramid) ValidLevel(level int) bool {
+	return level >= 0 && level <= p.MaxLevel()
+}
+
+type xmlDescriptor struct {
+	XMLName  xml.Name `xml:"Image"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	URL      string   `xml:"Url,attr,omitempty"`
+	Format   string   `xml:"Format,attr"`
+	Overlap  int      `xml:"Overlap,attr"`
+	TileSize int      `xml:"TileSize,attr"`
+	Size     struct {
+		Width  int `xml:"Width,attr"`
+		Height int `xml:"Height,attr"`
+	} `xml:"Size"`
+}
+
+// XML returns the .dzi descriptor. A non-empty tilesURL is emitted as the
+// Url attribute, which viewers such as OpenSeadragon use as the tiles base.
+func (p *Pyramid) XML(tilesURL string) []byte {
+	descriptor := xmlDescriptor{
+		Xmlns:    namespace,
+		URL:      tilesURL,
+		Format:   p.Format,
+		Overlap:  p.Overlap,
+		TileSize: p.TileSize,
+	}
+	descriptor.Size.Width = p.Width
+	descriptor.Size.Height = p.Height
+
+	data, _ := xml.Marshal(descriptor)
+	return append([]byte(xml.Header), data...)
+}
+
+// JSON returns the JSON variant of the descriptor understood by
+// OpenSeadragon, in which all values are strings.
+func (p *Pyramid) JSON(tilesURL string) []byte {
+	descriptor := map[string]interface{}{
+		"xmlns":    namespace,
+		"Format":   p.Format,
+		"Overlap":  strconv.Itoa(p.Overlap),
+		"TileSize": strconv.Itoa(p.TileSize),
+		"Size": map[string]string{
+			"Width":  strconv.Itoa(p.Width),
+			"Height": strconv.Itoa(p.Height),
+		},
+	}
+	if tilesURL != "" {
+		descriptor["Url"] = tilesURL
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"Image": descriptor})
+	return data
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+func ceilShift(n, shift int) int {
+	return (n + 1<<shift - 1) >> shift
+}
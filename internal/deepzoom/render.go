@@ -0,0 +1,103 @@
+package deepzoom
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/sync/errgroup"
+)
+
+// TileReader returns the decoded tile at the given level, column and row.
+type TileReader func(ctx context.Context, level, column, row int) (image.Image, error)
+
+// BestLevel returns the lowest level whose copy of the region, given in full
+// resolution pixels, is at least width by height pixels, so that the region
+// is only ever scaled down.
+func (p *Pyramid) BestLevel(region image.Rectangle, width, height int) int {
+	level := p.MaxLevel()
+	for level > 0 {
+		scale := float64(p.Scale(level - 1))
+		if float64(region.Dx())/scale < float64(width) || float64(region.Dy())/scale < float64(height) {
+			break
+		}
+		level--
+	}
+	return level
+}
+
+// LevelRegion converts a region in full resolution pixels into the pixels
+// of a level that cover it.
+func (p *Pyramid) LevelRegion(level int, region image.Rectangle) image.Rectangle {
+	scale := float64(p.Scale(level))
+	width, height := p.LevelSize(level)
+	return image.Rect(
+		int(math.Floor(float64(region.Min.X)/scale)),
+		int(math.Floor(float64(region.Min.Y)/scale)),
+		int(math.Ceil(float64(region.Max.X)/scale)),
+		int(math.Ceil(float64(region.Max.Y)/scale)),
+	).Intersect(image.Rect(0, 0, width, height))
+}
+
+// TileAt returns the tile whose area, excluding overlap, contains the given
+// level pixel.
+func (p *Pyramid) TileAt(x, y int) (column, row int) {
+	return x / p.TileSize, y / p.TileSize
+}
+
+// Compose assembles a region of a level from the tiles that cover it,
+// reading up to concurrency tiles at a time.
+func (p *Pyramid) Compose(ctx context.Context, level int, region image.Rectangle, read TileReader, concurrency int) (*image.RGBA, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	if region.Empty() {
+		return canvas, nil
+	}
+
+	firstColumn, firstRow := p.TileAt(region.Min.X, region.Min.Y)
+	lastColumn, lastRow := p.TileAt(region.Max.X-1, region.Max.Y-1)
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(max(concurrency, 1))
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			group.Go(func() error {
+				tile, err := read(ctx, level, column, row)
+				if err != nil {
+					return err
+				}
+
+				// Draw only the tile's own area, without the overlap, so
+				// that concurrent draws never touch the same pixels.
+				bounds := p.TileBounds(level, column, row)
+				own := p.TileArea(level, column, row).Intersect(region)
+				source := own.Min.Sub(bounds.Min).Add(tile.Bounds().Min)
+				draw.Draw(canvas, own.Sub(region.Min), tile, source, draw.Src)
+				return nil
+			})
+		}
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return canvas, nil
+}
+
+// Render returns a region of the image, given in full resolution pixels,
+// scaled to width by height pixels. It composes the region from the lowest
+// level that has enough detail and scales the result.
+func (p *Pyramid) Render(ctx context.Context, region image.Rectangle, width, height int, read TileReader, concurrency int) (*image.RGBA, error) {
+	level := p.BestLevel(region, width, height)
+	composed, err := p.Compose(ctx, level, p.LevelRegion(level, region), read, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	if composed.Bounds().Dx() == width && composed.Bounds().Dy() == height {
+		return composed, nil
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), composed, composed.Bounds(), xdraw.Src, nil)
+	return scaled, nil
+}
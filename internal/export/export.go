@@ -0,0 +1,233 @@
+// Package export writes image records as CSV, JSON Lines or Parquet, one row
+// per image, so that listings of any size can be streamed to the client.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// Export formats.
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// ContentType returns the media type of a supported format.
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// TilePlaceholder marks where a tile's path goes in AssetURLs.Tiles.
+const TilePlaceholder = "{tile}"
+
+// AssetURLs are the signed URLs of an image's assets. Tiles is a template;
+// tiles are fetched by replacing its TilePlaceholder with their Deep Zoom
+// path.
+type AssetURLs struct {
+	DZI       string
+	Tiles     string
+	Thumbnail string
+}
+
+// column extracts one value of a row. Values are strings, integers, times or
+// nil, which CSV writes as an empty field and JSON Lines and Parquet as null.
+type column struct {
+	name  string
+	value func(image *models.Image, urls *AssetURLs) any
+}
+
+var columns = []column{
+	{"id", func(i *models.Image, _ *AssetURLs) any { return i.ID }},
+	{"file_name", func(i *models.Image, _ *AssetURLs) any { return i.FileName }},
+	{"file_uid", func(i *models.Image, _ *AssetURLs) any { return i.FileUID }},
+	{"dataset_name", func(i *models.Image, _ *AssetURLs) any { return i.DatasetName }},
+	{"organ_type", func(i *models.Image, _ *AssetURLs) any { return i.OrganType }},
+	{"disease_type", func(i *models.Image, _ *AssetURLs) any { return optional(i.DiseaseType) }},
+	{"classification", func(i *models.Image, _ *AssetURLs) any { return optional(i.Classification) }},
+	{"sub_type", func(i *models.Image, _ *AssetURLs) any { return optional(i.SubType) }},
+	{"grade", func(i *models.Image, _ *AssetURLs) any { return optional(i.Grade) }},
+	{"width", func(i *models.Image, _ *AssetURLs) any { return int64(i.Width) }},
+	{"height", func(i *models.Image, _ *AssetURLs) any { return int64(i.Height) }},
+	{"size", func(i *models.Image, _ *AssetURLs) any { return i.Size }},
+	{"format", func(i *models.Image, _ *AssetURLs) any { return i.Format }},
+	{"dzi_gcs_path", func(i *models.Image, _ *AssetURLs) any { return i.DZIGCSPath }},
+	{"tiles_gcs_path", func(i *models.Image, _ *AssetURLs) any { return i.TilesGCSPath }},
+	{"thumbnail_gcs_path", func(i *models.Image, _ *AssetURLs) any { return i.ThumbnailGCSPath }},
+	{"created_at", func(i *models.Image, _ *AssetURLs) any { return i.CreatedAt.UTC() }},
+	{"updated_at", func(i *models.Image, _ *AssetURLs) any { return i.UpdatedAt.UTC() }},
+	{"version", func(i *models.Image, _ *AssetURLs) any { return i.Version }},
+}
+
+// urlColumns are only available when signed URLs are requested.
+var urlColumns = []column{
+	{"dzi_url", func(_ *models.Image, u *AssetURLs) any { return u.DZI }},
+	{"tiles_url", func(_ *models.Image, u *AssetURLs) any { return u.Tiles }},
+	{"thumbnail_url", func(_ *models.Image, u *AssetURLs) any { return u.Thumbnail }},
+}
+
+func optional(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// ParseColumns resolves a comma-separated list of column names. An empty
+// list selects every column, including the URL columns if withURLs is set.
+func ParseColumns(list string, withURLs bool) ([]string, error) {
+	available := columns
+	if withURLs {
+		available = append(slices.Clip(columns), urlColumns...)
+	}
+
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(available, func(c column) bool { return c.name == name }) {
+			if !withURLs && slices.ContainsFunc(urlColumns, func(c column) bool { return c.name == name }) {
+				return nil, models.NewError(models.ErrValidation, "column %q requires signed_urls=true", name)
+			}
+			return nil, models.NewError(models.ErrValidation, "unknown column %q", name)
+		}
+		if slices.Contains(names, name) {
+			return nil, models.NewError(models.ErrValidation, "column %q is selected twice", name)
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		for _, c := range available {
+			names = append(names, c.name)
+		}
+	}
+	return names, nil
+}
+
+// Writer writes image rows in one format.
+type Writer interface {
+	// Write writes the row of an image. urls may be nil unless URL
+	// columns are selected.
+	Write(image *models.Image, urls *AssetURLs) error
+	// Flush writes any buffered rows to the underlying writer.
+	Flush() error
+	// Close writes the remaining rows and anything the format needs after
+	// them. The writer may not be used afterwards.
+	Close() error
+}
+
+// NewWriter returns a writer of the selected columns, which must have been
+// resolved by ParseColumns. The CSV writer writes the header row first.
+func NewWriter(w io.Writer, format string, names []string) (Writer, error) {
+	selected := make([]column, len(names))
+	for i, name := range names {
+		index := slices.IndexFunc(columns, func(c column) bool { return c.name == name })
+		if index >= 0 {
+			selected[i] = columns[index]
+		} else if index = slices.IndexFunc(urlColumns, func(c column) bool { return c.name == name }); index >= 0 {
+			selected[i] = urlColumns[index]
+		} else {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	switch format {
+	case FormatCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write(names); err != nil {
+			return nil, err
+		}
+		return &csvWriter{out: out, columns: selected, record: make([]string, len(selected))}, nil
+	case FormatJSONL:
+		return &jsonlWriter{out: bufio.NewWriter(w), columns: selected}, nil
+	case FormatParquet:
+		return newParquetWriter(w, selected, parquetRowGroupRows), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	out     *csv.Writer
+	columns []column
+	record  []string
+}
+
+func (w *csvWriter) Write(image *models.Image, urls *AssetURLs) error {
+	for i, c := range w.columns {
+		switch value := c.value(image, urls).(type) {
+		case nil:
+			w.record[i] = ""
+		case string:
+			w.record[i] = value
+		case int64:
+			w.record[i] = strconv.FormatInt(value, 10)
+		case time.Time:
+			w.record[i] = value.Format(time.RFC3339Nano)
+		}
+	}
+	return w.out.Write(w.record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.out.Flush()
+	return w.out.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// jsonlWriter writes one JSON object per line with the keys in column
+// order, which encoding a map would not preserve.
+type jsonlWriter struct {
+	out     *bufio.Writer
+	columns []column
+}
+
+func (w *jsonlWriter) Write(image *models.Image, urls *AssetURLs) error {
+	w.out.WriteByte('{')
+	for i, c := range w.columns {
+		if i > 0 {
+			w.out.WriteByte(',')
+		}
+		key, _ := json.Marshal(c.name)
+		value, err := json.Marshal(c.value(image, urls))
+		if err != nil {
+			return err
+		}
+		w.out.Write(key)
+		w.out.WriteByte(':')
+		w.out.Write(value)
+	}
+	// Errors of earlier buffered writes are sticky and reported here.
+	_, err := w.out.WriteString("}\n")
+	return err
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.out.Flush()
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.Flush()
+}
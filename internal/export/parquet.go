@@ -0,0 +1,117 @@
+package export
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupRows is how many rows a Parquet row group holds. A row
+// group is buffered in memory until it is full and then written, so this
+// bounds the memory of an export.
+const parquetRowGroupRows = 10000
+
+// parquetWriter writes Parquet, one row group at a time. The footer, which
+// describes the row groups, is written by Close.
+type parquetWriter struct {
+	out     *parquet.Writer
+	columns []column
+	levels  []int // Definition level of present values by column
+	row     parquet.Row
+}
+
+func newParquetWriter(w io.Writer, columns []column, rowGroupRows int64) *parquetWriter {
+	fields := make([]parquet.Field, len(columns))
+	levels := make([]int, len(columns))
+	for i, c := range columns {
+		node := parquetNode(c)
+		if node.Optional() {
+			levels[i] = 1
+		}
+		fields[i] = &parquetField{Node: node, name: c.name}
+	}
+	schema := parquet.NewSchema("image", &parquetGroup{Group: parquet.Group{}, fields: fields})
+	return &parquetWriter{
+		out:     parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy), parquet.MaxRowsPerRowGroup(rowGroupRows)),
+		columns: columns,
+		levels:  levels,
+		row:     make(parquet.Row, len(columns)),
+	}
+}
+
+// parquetNode returns the Parquet type of a column. Column types are fixed,
+// so they are taken from the values of an empty image; only the optional
+// labels are nil there, and they are strings.
+func parquetNode(c column) parquet.Node {
+	switch c.value(&models.Image{}, &AssetURLs{}).(type) {
+	case int64:
+		return parquet.Int(64)
+	case time.Time:
+		return parquet.Timestamp(parquet.Microsecond)
+	case nil:
+		return parquet.Optional(parquet.String())
+	default:
+		return parquet.String()
+	}
+}
+
+func (w *parquetWriter) Write(image *models.Image, urls *AssetURLs) error {
+	for i, c := range w.columns {
+		var value parquet.Value
+		switch v := c.value(image, urls).(type) {
+		case nil:
+			value = parquet.NullValue()
+		case string:
+			value = parquet.ByteArrayValue([]byte(v))
+		case int64:
+			value = parquet.Int64Value(v)
+		case time.Time:
+			value = parquet.Int64Value(v.UnixMicro())
+		}
+		level := w.levels[i]
+		if value.IsNull() {
+			level = 0
+		}
+		w.row[i] = value.Level(0, level, i)
+	}
+	_, err := w.out.WriteRows([]parquet.Row{w.row})
+	return err
+}
+
+// Flush does nothing: row groups are written as they fill up, and writing
+// one early would only make the file less efficient to read.
+func (w *parquetWriter) Flush() error {
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	return w.out.Close()
+}
+
+// parquetGroup is the root of the schema. It keeps the columns in the
+// selected order, where parquet.Group sorts them by name.
+type parquetGroup struct {
+	parquet.Group
+	fields []parquet.Field
+}
+
+func (g *parquetGroup) Fields() []parquet.Field {
+	return g.fields
+}
+
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+func (f *parquetField) Name() string {
+	return f.name
+}
+
+// Value is only used to write Go values, which the writer does not do.
+func (f *parquetField) Value(base reflect.Value) reflect.Value {
+	return reflect.Value{}
+}
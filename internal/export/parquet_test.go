@@ -0,0 +1,103 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetWriter(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	grade := "2"
+	images := []*models.Image{
+		{ID: "a", Width: 1000, Grade: &grade, CreatedAt: created},
+		{ID: "b", Width: 2000, CreatedAt: created.Add(time.Hour)},
+		{ID: "c", Width: 3000, Grade: &grade, CreatedAt: created.Add(2 * time.Hour)},
+	}
+	names := []string{"width", "id", "grade", "created_at"}
+	selected := make([]column, len(names))
+	for i, name := range names {
+		for _, c := range columns {
+			if c.name == name {
+				selected[i] = c
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	w := newParquetWriter(&out, selected, 2)
+	for _, image := range images {
+		if err := w.Write(image, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, path := range file.Schema().Columns() {
+		got = append(got, strings.Join(path, "."))
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("columns = %v, want %v", got, names)
+	}
+	if n := len(file.RowGroups()); n != 2 {
+		t.Errorf("row groups = %d, want 2", n)
+	}
+
+	var rows []parquet.Row
+	for _, group := range file.RowGroups() {
+		reader := group.Rows()
+		buf := make([]parquet.Row, group.NumRows())
+		n, err := reader.ReadRows(buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+		rows = append(rows, buf[:n]...)
+		reader.Close()
+	}
+	if len(rows) != len(images) {
+		t.Fatalf("rows = %d, want %d", len(rows), len(images))
+	}
+	for i, image := range images {
+		row := rows[i]
+		if row[0].Int64() != int64(image.Width) || row[1].String() != image.ID {
+			t.Errorf("row %d = %v, want width %d and id %s", i, row, image.Width, image.ID)
+		}
+		if row[2].IsNull() != (image.Grade == nil) {
+			t.Errorf("row %d grade = %v, want %v", i, row[2], image.Grade)
+		}
+		if row[3].Int64() != image.CreatedAt.UnixMicro() {
+			t.Errorf("row %d created_at = %d, want %d", i, row[3].Int64(), image.CreatedAt.UnixMicro())
+		}
+	}
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, FormatParquet, []string{"id", "grade"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.NumRows() != 0 || len(file.Schema().Columns()) != 2 {
+		t.Errorf("file has %d rows and %d columns, want 0 and 2", file.NumRows(), len(file.Schema().Columns()))
+	}
+}
@@ -0,0 +1,82 @@
+// Package geojson encodes slide annotations as GeoJSON (RFC 7946) feature
+// collections. Coordinates are full resolution slide pixels with the y axis
+// pointing down, as used by QuPath and similar tools, not longitude and
+// latitude.
+package geojson
+
+import (
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// ContentType is the media type of GeoJSON documents.
+const ContentType = "application/geo+json"
+
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Geometry   *Geometry  `json:"geometry"`
+	Properties Properties `json:"properties"`
+}
+
+// Geometry holds the coordinates of a Point ([x, y]) or a Polygon (a list
+// containing one closed ring of [x, y] positions).
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type Properties struct {
+	ImageID   string    `json:"image_id"`
+	Label     string    `json:"label"`
+	Shape     string    `json:"shape"` // The annotation geometry type, since rectangles become polygons
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FromAnnotations returns a feature collection with one feature per annotation.
+func FromAnnotations(annotations []*models.Annotation) *FeatureCollection {
+	collection := &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*Feature, 0, len(annotations)),
+	}
+	for _, annotation := range annotations {
+		collection.Features = append(collection.Features, &Feature{
+			Type:     "Feature",
+			ID:       annotation.ID,
+			Geometry: geometry(&annotation.Geometry),
+			Properties: Properties{
+				ImageID:   annotation.ImageID,
+				Label:     annotation.Label,
+				Shape:     annotation.Geometry.Type,
+				CreatedBy: annotation.CreatedBy,
+				CreatedAt: annotation.CreatedAt,
+				UpdatedBy: annotation.UpdatedBy,
+				UpdatedAt: annotation.UpdatedAt,
+			},
+		})
+	}
+	return collection
+}
+
+func geometry(g *models.Geometry) *Geometry {
+	if g.Type == models.GeometryPoint {
+		p := g.Points[0]
+		return &Geometry{Type: "Point", Coordinates: [2]float64{p.X, p.Y}}
+	}
+
+	ring := g.Ring()
+	positions := make([][2]float64, len(ring))
+	for i, p := range ring {
+		positions[i] = [2]float64{p.X, p.Y}
+	}
+	return &Geometry{Type: "Polygon", Coordinates: [][][2]float64{positions}}
+}
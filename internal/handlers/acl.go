@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+type ACLHandler struct {
+	accessService *service.AccessService
+}
+
+func NewACLHandler(accessService *service.AccessService) *ACLHandler {
+	return &ACLHandler{
+		accessService: accessService,
+	}
+}
+
+// GetDatasetGrants lists the grants of a dataset.
+func (h *ACLHandler) GetDatasetGrants(c *gin.Context) {
+	datasetName := c.Param("dataset_name")
+	grants, err := h.accessService.ListGrants(c.Request.Context(), datasetName)
+	if err != nil {
+		respondError(c, err, "grant_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+// PutDatasetGrant grants a user or group a permission on a dataset.
+func (h *ACLHandler) PutDatasetGrant(c *gin.Context) {
+	var grantRequest models.DatasetGrantRequest
+	if err := c.ShouldBindJSON(&grantRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	grant, err := h.accessService.GrantAccess(c.Request.Context(), c.Param("dataset_name"), &grantRequest)
+	if err != nil {
+		respondError(c, err, "grant_update_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Access granted successfully", "grant": grant})
+}
+
+// DeleteDatasetGrant revokes the grant of a user or group on a dataset.
+func (h *ACLHandler) DeleteDatasetGrant(c *gin.Context) {
+	subject := c.Query("subject")
+	if subject == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject_missing", "message": "The subject query parameter is required."})
+		return
+	}
+
+	if err := h.accessService.RevokeAccess(c.Request.Context(), c.Param("dataset_name"), subject); err != nil {
+		respondError(c, err, "grant_deletion_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked successfully"})
+}
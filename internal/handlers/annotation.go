@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/geojson"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+type AnnotationHandler struct {
+	annotationService *service.AnnotationService
+}
+
+func NewAnnotationHandler(annotationService *service.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{
+		annotationService: annotationService,
+	}
+}
+
+// GetAnnotations lists the annotations of an image. ?format=geojson or an
+// Accept header preferring application/geo+json exports them as a GeoJSON
+// feature collection instead.
+func (h *AnnotationHandler) GetAnnotations(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, geojson.ContentType) == geojson.ContentType {
+		format = "geojson"
+	}
+	if format != "" && format != "json" && format != "geojson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_format", "message": "format must be \"json\" or \"geojson\"."})
+		return
+	}
+
+	imageID := c.Param("image_id")
+	annotations, err := h.annotationService.ListAnnotations(c.Request.Context(), imageID)
+	if err != nil {
+		respondError(c, err, "annotation_retrieval_error")
+		return
+	}
+
+	if format == "geojson" {
+		data, _ := json.Marshal(geojson.FromAnnotations(annotations))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", imageID+"_annotations.geojson"))
+		c.Data(http.StatusOK, geojson.ContentType, data)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+}
+
+// CreateAnnotation draws a new annotation on an image.
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	var createRequest models.AnnotationCreateRequest
+	if err := c.ShouldBindJSON(&createRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	annotation, err := h.annotationService.CreateAnnotation(c.Request.Context(), c.Param("image_id"), &createRequest)
+	if err != nil {
+		respondError(c, err, "annotation_creation_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Annotation created successfully", "annotation": annotation})
+}
+
+// GetAnnotationByID retrieves one annotation of an image.
+func (h *AnnotationHandler) GetAnnotationByID(c *gin.Context) {
+	annotation, err := h.annotationService.GetAnnotation(c.Request.Context(), c.Param("image_id"), c.Param("annotation_id"))
+	if err != nil {
+		respondError(c, err, "annotation_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotation": annotation})
+}
+
+// UpdateAnnotationByID changes the label or geometry of an annotation.
+func (h *AnnotationHandler) UpdateAnnotationByID(c *gin.Context) {
+	var updateRequest models.AnnotationUpdateRequest
+	if err := c.ShouldBindJSON(&updateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	annotation, err := h.annotationService.UpdateAnnotation(c.Request.Context(), c.Param("image_id"), c.Param("annotation_id"), &updateRequest)
+	if err != nil {
+		respondError(c, err, "annotation_update_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Annotation updated successfully", "annotation": annotation})
+}
+
+// DeleteAnnotationByID removes an annotation.
+func (h *AnnotationHandler) DeleteAnnotationByID(c *gin.Context) {
+	if err := h.annotationService.DeleteAnnotation(c.Request.Context(), c.Param("image_id"), c.Param("annotation_id")); err != nil {
+		respondError(c, err, "annotation_deletion_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Annotation deleted successfully"})
+}
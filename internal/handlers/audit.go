@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetImageHistory lists the recorded changes of an image, newest first.
+func (h *AuditHandler) GetImageHistory(c *gin.Context) {
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.auditService.ImageHistory(c.Request.Context(), c.Param("image_id"), filter)
+	if err != nil {
+		respondError(c, err, "audit_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetAuditLog queries the audit log of every image by user, image and time range.
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+	filter.ImageID = c.Query("image_id")
+	filter.Actor = c.Query("user")
+
+	list, err := h.auditService.Query(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err, "audit_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// parseAuditFilter reads the time range and pagination parameters shared by
+// the audit endpoints. Times are RFC 3339.
+func parseAuditFilter(c *gin.Context) (*models.AuditFilter, bool) {
+	filter := &models.AuditFilter{PageToken: c.Query("page_token")}
+
+	for name, field := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_time_range", "message": fmt.Sprintf("%s must be an RFC 3339 time.", name)})
+			return nil, false
+		}
+		*field = t
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_pagination", "message": "limit must be an integer."})
+			return nil, false
+		}
+		filter.Limit = n
+	}
+	return filter, true
+}
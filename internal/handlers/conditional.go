@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notModified evaluates If-None-Match and If-Modified-Since (RFC 9110,
+// section 13.2.2) for a GET request. If-Modified-Since is ignored when
+// If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a resolution of one second.
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagListMatches reports whether a comma-separated list of entity tags
+// matches etag using the weak comparison function.
+func etagListMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// GetImageDZI returns the Deep Zoom descriptor of an image, generated from
+// its record rather than read from the stored .dzi file. The XML form is the
+// default; ?format=json or an Accept header preferring JSON selects the JSON
+// variant. The descriptor's Url points viewers at the image's tiles on the proxy.
+func (h *ImageHandler) GetImageDZI(c *gin.Context) {
+	format := c.Query("format")
+	switch format {
+	case "":
+		if c.NegotiateFormat(gin.MIMEXML, gin.MIMEXML2, gin.MIMEJSON) == gin.MIMEJSON {
+			format = "json"
+		}
+	case "json", "xml":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_format", "message": "format must be \"xml\" or \"json\"."})
+		return
+	}
+
+	image, err := h.imageService.GetImage(c.Request.Context(), c.Param("image_id"))
+	if err != nil {
+		respondError(c, err, "image_retrieval_error")
+		return
+	}
+	pyramid, err := deepzoom.ForImage(image)
+	if err != nil {
+		respondError(c, err, "dzi_generation_error")
+		return
+	}
+
+	tilesURL := proxyURL(c, models.ObjectName(image.TilesGCSPath))
+	c.Header("Cache-Control", "private, no-cache")
+	if format == "json" {
+		c.Data(http.StatusOK, gin.MIMEJSON, pyramid.JSON(tilesURL))
+		return
+	}
+	c.Data(http.StatusOK, gin.MIMEXML, pyramid.XML(tilesURL))
+}
+
+// proxyURL returns the absolute path at which the proxy serves the objects
+// under a prefix, derived from the API root of the current /images request.
+func proxyURL(c *gin.Context, prefix string) string {
+	apiRoot := c.Request.URL.Path
+	if i := strings.LastIndex(apiRoot, "/images/"); i >= 0 {
+		apiRoot = apiRoot[:i]
+	}
+	return apiRoot + "/proxy/" + strings.Trim(prefix, "/") + "/"
+}
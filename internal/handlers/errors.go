@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// retryAfterSeconds is the Retry-After value sent with 503 responses.
+const retryAfterSeconds = "1"
+
+// respondError writes the error response for err, mapping the models error
+// kinds to their HTTP status. Unclassified errors are reported as a 500 with
+// the given fallback code.
+func respondError(c *gin.Context, err error, fallbackCode string) {
+	status, code := errorStatus(err, fallbackCode)
+	if status == http.StatusServiceUnavailable {
+		c.Header("Retry-After", retryAfterSeconds)
+	}
+	c.JSON(status, gin.H{"error": code, "message": err.Error()})
+}
+
+func errorStatus(err error, fallbackCode string) (int, string) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, models.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, models.ErrPrecondition):
+		return http.StatusPreconditionFailed, "precondition_failed"
+	case errors.Is(err, models.ErrValidation):
+		return http.StatusUnprocessableEntity, "validation_error"
+	case errors.Is(err, models.ErrPermission):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, models.ErrUnavailable):
+		return http.StatusServiceUnavailable, "unavailable"
+	default:
+		return http.StatusInternalServerError, fallbackCode
+	}
+}
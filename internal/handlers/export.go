@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/export"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+	"github.com/histopathai/image-catalog-service/internal/signedurl"
+)
+
+const (
+	// exportFlushRows is how many rows are buffered before they are sent.
+	exportFlushRows = 100
+	// exportStatusTrailer reports whether the export finished, because
+	// the status code has been sent by the time a streaming error occurs.
+	exportStatusTrailer = "X-Export-Status"
+	// signedPathPrefix is the route that serves signed URLs.
+	signedPathPrefix = "/api/v1/signed/"
+	// signedTokenParam is the query parameter carrying the token of a
+	// signed URL; the access log redacts it.
+	signedTokenParam = "token"
+)
+
+// ExportHandler streams filtered image listings as files for offline use,
+// e.g. as training manifests.
+type ExportHandler struct {
+	imageService  *service.ImageService
+	signer        *signedurl.Signer // Nil if signed URLs are disabled
+	publicBaseURL string
+}
+
+func NewExportHandler(imageService *service.ImageService, cfg *config.Config) *ExportHandler {
+	var signer *signedurl.Signer
+	if cfg.Proxy.SigningKey != "" {
+		signer = signedurl.New([]byte(cfg.Proxy.SigningKey), cfg.Proxy.SignedURLTTL)
+	}
+	return &ExportHandler{
+		imageService:  imageService,
+		signer:        signer,
+		publicBaseURL: cfg.Proxy.PublicBaseURL,
+	}
+}
+
+// ExportImages writes every image matching the listing filters as CSV, JSON
+// Lines or Parquet. Rows are sent as they are read, and Parquet a row group at
+// a time, so the status is 200 once the first row is out; a failure after that
+// ends the body early and sets the X-Export-Status trailer to "error" instead
+// of "complete".
+func (h *ExportHandler) ExportImages(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	switch format {
+	case export.FormatCSV, export.FormatJSONL, export.FormatParquet:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_format", "message": "format must be csv, jsonl or parquet."})
+		return
+	}
+
+	signed := false
+	if value := c.Query("signed_urls"); value != "" {
+		var err error
+		if signed, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "signed_urls must be true or false."})
+			return
+		}
+	}
+	if signed && h.signer == nil {
+		respondError(c, models.NewError(models.ErrUnavailable, "signed URLs are not configured"), "image_export_error")
+		return
+	}
+
+	columns, err := export.ParseColumns(c.Query("columns"), signed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_columns", "message": err.Error()})
+		return
+	}
+	filter, ok := parseImageFilter(c)
+	if !ok {
+		return
+	}
+
+	// Exports can outlast the server's write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	var out export.Writer
+	rows := 0
+	start := func() error {
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"images-%s.%s\"", time.Now().UTC().Format("20060102-150405"), format))
+		c.Header("Trailer", exportStatusTrailer)
+		c.Status(http.StatusOK)
+		writer, err := export.NewWriter(c.Writer, format, columns)
+		out = writer
+		return err
+	}
+
+	err = h.imageService.ExportImages(c.Request.Context(), filter, func(image *models.Image) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		var urls *export.AssetURLs
+		if signed {
+			urls = h.assetURLs(image)
+		}
+		if err := out.Write(image, urls); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err != nil && out == nil {
+		respondError(c, err, "image_export_error")
+		return
+	}
+	if err == nil {
+		err = out.Close()
+	}
+
+	if err != nil {
+		slog.Error("Image export ended early", "rows", rows, "error", err)
+		c.Writer.Header().Set(exportStatusTrailer, "error")
+		return
+	}
+	c.Writer.Header().Set(exportStatusTrailer, "complete")
+}
+
+// assetURLs signs the image's DZI, tiles prefix and thumbnail.
+func (h *ExportHandler) assetURLs(image *models.Image) *export.AssetURLs {
+	urls := &export.AssetURLs{
+		DZI:       h.signedURL(image.ID, models.ObjectName(image.DZIGCSPath)),
+		Thumbnail: h.signedURL(image.ID, models.ObjectName(image.ThumbnailGCSPath)),
+	}
+	if tiles := strings.TrimSuffix(models.ObjectName(image.TilesGCSPath), "/"); tiles != "" {
+		urls.Tiles = h.signedURL(image.ID, tiles+"/")
+	}
+	return urls
+}
+
+// signedURL returns the URL of the signed proxy route for the image's scope,
+// which is an object name or a prefix ending in "/". For a prefix, the URL
+// holds export.TilePlaceholder where the object's path goes. The token is a
+// query parameter so that it stays out of the request path.
+func (h *ExportHandler) signedURL(imageID, scope string) string {
+	if scope == "" {
+		return ""
+	}
+	segments := strings.Split(scope, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	path := strings.Join(segments, "/")
+	if strings.HasSuffix(scope, "/") {
+		path += export.TilePlaceholder
+	}
+	query := url.Values{signedTokenParam: {h.signer.Token(imageID, scope)}}
+	return h.publicBaseURL + signedPathPrefix + url.PathEscape(imageID) + "/" + path + "?" + query.Encode()
+}
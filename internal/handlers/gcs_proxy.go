@@ -1,45 +1,252 @@
 package handlers
 
 import (
-	"context"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"unicode"
 
-	"cloud.google.com/go/storage"
 	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"github.com/histopathai/image-catalog-service/internal/service"
+	"github.com/histopathai/image-catalog-service/internal/signedurl"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
 )
 
+// GCSProxyHandler serves image assets from the configured object store,
+// which is a GCS bucket unless another backend is selected.
 type GCSProxyHandler struct {
-	GCSClient  *storage.Client
-	BucketName string
+	store           repository.ObjectStore
+	imageService    *service.ImageService
+	tiles           *tilecache.Cache
+	load            tilecache.LoadFunc
+	allowedPrefixes []string
+	cacheControl    map[string]string // By models.ObjectKind*, "" for other objects
+	signer          *signedurl.Signer // Nil if signed URLs are disabled
 }
 
-func NewGCSProxyHandler(projectID, bucketName string) (*GCSProxyHandler, error) {
-	client, err := storage.NewClient(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+func NewGCSProxyHandler(store repository.ObjectStore, imageService *service.ImageService, tiles *tilecache.Cache, proxyCfg config.ProxyConfig) *GCSProxyHandler {
+	allowedPrefixes := make([]string, 0, len(proxyCfg.AllowedPrefixes))
+	for _, prefix := range proxyCfg.AllowedPrefixes {
+		if prefix = strings.Trim(prefix, "/ "); prefix != "" {
+			allowedPrefixes = append(allowedPrefixes, prefix+"/")
+		}
+	}
+
+	var signer *signedurl.Signer
+	if proxyCfg.SigningKey != "" {
+		signer = signedurl.New([]byte(proxyCfg.SigningKey), proxyCfg.SignedURLTTL)
 	}
 
 	return &GCSProxyHandler{
-		GCSClient:  client,
-		BucketName: bucketName,
-	}, nil
+		store:           store,
+		imageService:    imageService,
+		tiles:           tiles,
+		load:            tiles.StoreLoader(store),
+		allowedPrefixes: allowedPrefixes,
+		cacheControl: map[string]string{
+			models.ObjectKindTile:      proxyCfg.CacheControlTile,
+			models.ObjectKindDZI:       proxyCfg.CacheControlDZI,
+			models.ObjectKindThumbnail: proxyCfg.CacheControlThumbnail,
+			"":                         proxyCfg.CacheControlDefault,
+		},
+		signer: signer,
+	}
 }
 
+// ProxyObject streams an object that belongs to a catalogued image the
+// caller may read, or that lies under an allowlisted prefix. Every failure,
+// including invalid paths and denied access, yields the same 404 so the
+// response does not reveal which objects exist.
 func (h *GCSProxyHandler) ProxyObject(c *gin.Context) {
-	objectPath := strings.TrimPrefix(c.Param("objectPath"), "/") // 🔥 düzeltme
+	objectPath, err := proxyObjectPath(c)
+	if err != nil {
+		h.notFound(c, "", err)
+		return
+	}
+
+	// Only serve objects of images the caller may read
+	kind := ""
+	if !h.isAllowlisted(objectPath) {
+		image, err := h.imageService.AuthorizeObject(c.Request.Context(), objectPath)
+		if err != nil {
+			h.notFound(c, objectPath, err)
+			return
+		}
+		kind = image.ObjectKind(objectPath)
+	}
+	h.serveObject(c, objectPath, kind)
+}
+
+// ProxySignedObject serves an image asset to a request carrying a signed
+// token for the image instead of credentials (see signedurl). The object
+// must still belong to the image, and deleting the image revokes its signed
+// URLs. Failures yield the same 404 as ProxyObject.
+func (h *GCSProxyHandler) ProxySignedObject(c *gin.Context) {
+	if h.signer == nil {
+		h.notFound(c, "", models.NewError(models.ErrNotFound, "signed URLs are disabled"))
+		return
+	}
+	objectPath, err := proxyObjectPath(c)
+	if err != nil {
+		h.notFound(c, "", err)
+		return
+	}
+	imageID := c.Param("image_id")
+	if err := h.signer.Verify(c.Query(signedTokenParam), imageID, objectPath); err != nil {
+		h.notFound(c, objectPath, err)
+		return
+	}
+
+	image, err := h.imageService.ObjectOwner(c.Request.Context(), imageID, objectPath)
+	if err != nil {
+		h.notFound(c, objectPath, err)
+		return
+	}
+	h.serveObject(c, objectPath, image.ObjectKind(objectPath))
+}
+
+// serveObject writes an authorized object, honouring conditional and range
+// requests. kind selects the Cache-Control value.
+func (h *GCSProxyHandler) serveObject(c *gin.Context, objectPath, kind string) {
+	// Tiles are written once per path; other objects may be rewritten when
+	// the image is re-processed.
+	var entry *tilecache.Entry
+	var err error
+	if kind == models.ObjectKindTile {
+		entry, err = h.tiles.Get(c.Request.Context(), objectPath, h.load)
+	} else {
+		entry, err = h.tiles.GetRevalidated(c.Request.Context(), objectPath, h.store.Stat, h.load)
+	}
+	if err != nil {
+		h.notFound(c, objectPath, err)
+		return
+	}
+
+	c.Header("ETag", entry.ETag)
+	c.Header("Last-Modified", entry.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", h.cacheControl[kind])
 
-	ctx := context.Background()
-	rc, err := h.GCSClient.Bucket(h.BucketName).Object(objectPath).NewReader(ctx)
+	if notModified(c.Request, entry.ETag, entry.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	byteRange, err := requestedRange(c.Request, entry.Size, entry.ETag, entry.LastModified)
 	if err != nil {
-		c.String(http.StatusNotFound, fmt.Sprintf("object not found: %s", err.Error()))
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", entry.Size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "range_not_satisfiable", "message": err.Error()})
 		return
 	}
-	defer rc.Close()
 
-	c.Header("Content-Type", rc.ContentType())
-	c.Status(http.StatusOK)
-	_, _ = io.Copy(c.Writer, rc)
+	offset, length, status := int64(0), int64(-1), http.StatusOK
+	if byteRange != nil {
+		offset, length, status = byteRange.start, byteRange.length, http.StatusPartialContent
+	}
+
+	var body io.Reader
+	if entry.Data != nil {
+		data := entry.Data
+		if byteRange != nil {
+			data = data[offset : offset+length]
+		}
+		body = bytes.NewReader(data)
+		c.Header("Content-Length", strconv.Itoa(len(data)))
+	} else {
+		// Pin the version so the body matches the validators sent above.
+		rc, err := h.store.OpenRange(c.Request.Context(), objectPath, entry.Version, offset, length)
+		if err != nil {
+			h.notFound(c, objectPath, err)
+			return
+		}
+		defer rc.Close()
+		body = rc
+		if byteRange == nil {
+			length = entry.Size
+		}
+		c.Header("Content-Length", strconv.FormatInt(length, 10))
+	}
+
+	c.Header("Content-Type", entry.ContentType)
+	if byteRange != nil {
+		c.Header("Content-Range", byteRange.contentRange(entry.Size))
+	}
+	c.Status(status)
+	_, _ = io.Copy(c.Writer, body)
+}
+
+// GetCacheStats reports the hit, miss and eviction counters of the tile cache.
+func (h *GCSProxyHandler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"cache": h.tiles.Stats()})
+}
+
+// InvalidateImageCache drops the cached objects of an image after it has
+// been re-processed. Only the cache of the instance serving the request is
+// cleared; see ImageService.InvalidateImageCache.
+func (h *GCSProxyHandler) InvalidateImageCache(c *gin.Context) {
+	removed, err := h.imageService.InvalidateImageCache(c.Request.Context(), c.Param("image_id"))
+	if err != nil {
+		respondError(c, err, "cache_invalidation_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image cache invalidated", "removed_entries": removed})
+}
+
+func (h *GCSProxyHandler) isAllowlisted(objectPath string) bool {
+	for _, prefix := range h.allowedPrefixes {
+		if strings.HasPrefix(objectPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// notFound logs why an object was not served and writes the uniform 404.
+func (h *GCSProxyHandler) notFound(c *gin.Context, objectPath string, err error) {
+	level := slog.LevelWarn
+	if errors.Is(err, models.ErrNotFound) {
+		level = slog.LevelDebug
+	}
+	slog.Log(c.Request.Context(), level, "Refused to proxy object", "object", objectPath, "error", err)
+
+	// Drop validators that may already be set and keep the refusal out of caches.
+	c.Writer.Header().Del("ETag")
+	c.Writer.Header().Del("Last-Modified")
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "Object not found."})
+}
+
+// proxyObjectPath extracts the object name from the request and rejects
+// names that are not in canonical form: empty, "." or ".." segments,
+// backslashes, control characters and percent-encoded slashes.
+func proxyObjectPath(c *gin.Context) (string, error) {
+	raw := strings.ToLower(c.Request.URL.EscapedPath())
+	for _, encoded := range []string{"%2f", "%5c", "%00"} {
+		if strings.Contains(raw, encoded) {
+			return "", models.NewError(models.ErrValidation, "object path contains an encoded separator")
+		}
+	}
+
+	objectPath := strings.TrimPrefix(c.Param("objectPath"), "/")
+	if objectPath == "" {
+		return "", models.NewError(models.ErrValidation, "object path is empty")
+	}
+	if strings.ContainsFunc(objectPath, func(r rune) bool { return r == '\\' || unicode.IsControl(r) }) {
+		return "", models.NewError(models.ErrValidation, "object path contains invalid characters")
+	}
+	for _, segment := range strings.Split(objectPath, "/") {
+		switch segment {
+		case "", ".", "..":
+			return "", models.NewError(models.ErrValidation, "object path contains an empty or relative segment")
+		}
+	}
+	return objectPath, nil
 }
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+func TestProxyObjectPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{name: "object", target: "/proxy/slides/b1/thumbnail.jpg", want: "slides/b1/thumbnail.jpg"},
+		{name: "escaped space", target: "/proxy/slides/b%201/tile.jpeg", want: "slides/b 1/tile.jpeg"},
+		{name: "dots in name", target: "/proxy/slides/b1/..tile.jpeg", want: "slides/b1/..tile.jpeg"},
+		{name: "empty", target: "/proxy/", wantErr: true},
+		{name: "parent segment", target: "/proxy/slides/../secret.txt", wantErr: true},
+		{name: "escaped parent segment", target: "/proxy/slides/%2e%2e/secret.txt", wantErr: true},
+		{name: "current segment", target: "/proxy/slides/./b1/thumbnail.jpg", wantErr: true},
+		{name: "empty segment", target: "/proxy/slides//b1/thumbnail.jpg", wantErr: true},
+		{name: "trailing slash", target: "/proxy/slides/b1/", wantErr: true},
+		{name: "encoded slash", target: "/proxy/slides%2fb1/thumbnail.jpg", wantErr: true},
+		{name: "encoded slash upper case", target: "/proxy/slides%2Fb1/thumbnail.jpg", wantErr: true},
+		{name: "encoded backslash", target: "/proxy/slides%5cb1/thumbnail.jpg", wantErr: true},
+		{name: "encoded NUL", target: "/proxy/slides/b1%00.jpg", wantErr: true},
+		{name: "control character", target: "/proxy/slides/b1%0a.jpg", wantErr: true},
+	}
+	handler := func(c *gin.Context) {
+		objectPath, err := proxyObjectPath(c)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, objectPath)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodGet, "/proxy/*objectPath", tt.target, nil, "")
+			if tt.wantErr {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("proxyObjectPath() = %q, want an error", w.Body)
+				}
+				return
+			}
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("proxyObjectPath() = %d %q, want %q", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyObjectAccess(t *testing.T) {
+	images := []*models.Image{
+		{ID: "b1", DatasetName: "breast", ThumbnailGCSPath: "gs://bucket/slides/b1/thumbnail.jpg", TilesGCSPath: "gs://bucket/slides/b1/tiles/"},
+		{ID: "c1", DatasetName: "colon", ThumbnailGCSPath: "gs://bucket/slides/c1/thumbnail.jpg"},
+	}
+	env := newTestEnv(t, images...)
+	env.cfg.Proxy.AllowedPrefixes = []string{"/public/"}
+	h := NewGCSProxyHandler(env.store, env.svc, env.tiles, env.cfg.Proxy)
+
+	for _, name := range []string{"slides/b1/thumbnail.jpg", "slides/b1/tiles/0/0_0.jpeg", "slides/c1/thumbnail.jpg", "slides/orphan.jpg", "public/logo.png", "publicity.png"} {
+		if err := env.store.Write(context.Background(), name, "image/jpeg", bytes.NewReader([]byte(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		object    string
+		principal *auth.Principal
+		status    int
+	}{
+		{name: "thumbnail", object: "slides/b1/thumbnail.jpg", principal: testReader, status: http.StatusOK},
+		{name: "tile", object: "slides/b1/tiles/0/0_0.jpeg", principal: testReader, status: http.StatusOK},
+		{name: "unreadable dataset", object: "slides/c1/thumbnail.jpg", principal: testReader, status: http.StatusNotFound},
+		{name: "admin", object: "slides/c1/thumbnail.jpg", principal: testAdmin, status: http.StatusOK},
+		{name: "no grant", object: "slides/b1/thumbnail.jpg", principal: testNobody, status: http.StatusNotFound},
+		{name: "not catalogued", object: "slides/orphan.jpg", principal: testAdmin, status: http.StatusNotFound},
+		{name: "missing asset", object: "slides/b1/tiles/0/1_0.jpeg", principal: testAdmin, status: http.StatusNotFound},
+		{name: "allowlisted", object: "public/logo.png", principal: testNobody, status: http.StatusOK},
+		{name: "prefix is a directory", object: "publicity.png", principal: testAdmin, status: http.StatusNotFound},
+		{name: "traversal out of an allowlisted prefix", object: "public/../slides/c1/thumbnail.jpg", principal: testNobody, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.ProxyObject, http.MethodGet, "/proxy/*objectPath", "/proxy/"+tt.object, tt.principal, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusOK && w.Body.String() != tt.object {
+				t.Errorf("body = %q, want %q", w.Body, tt.object)
+			}
+			if tt.status == http.StatusNotFound && w.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
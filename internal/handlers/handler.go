@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/manifest"
 	"github.com/histopathai/image-catalog-service/internal/models"
 	"github.com/histopathai/image-catalog-service/internal/service"
 )
@@ -18,6 +25,23 @@ func NewImageHandler(imgService *service.ImageService) *ImageHandler {
 	}
 }
 
+// CreateImage registers a new image record.
+func (h *ImageHandler) CreateImage(c *gin.Context) {
+	var createRequest models.ImageCreateRequest
+	if err := c.ShouldBindJSON(&createRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	image, err := h.imageService.CreateImage(c.Request.Context(), &createRequest)
+	if err != nil {
+		respondError(c, err, "image_creation_error")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Image created successfully", "image": image})
+}
+
 // GetImageByID retrieves an image by its ID.
 func (h *ImageHandler) GetImageByID(c *gin.Context) {
 	imageId := c.Param("image_id")
@@ -27,17 +51,20 @@ func (h *ImageHandler) GetImageByID(c *gin.Context) {
 	}
 	image, err := h.imageService.GetImage(c.Request.Context(), imageId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "image_retrieval_error", "message": err.Error()})
+		respondError(c, err, "image_retrieval_error")
 		return
 	}
-	if image == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "image_not_found", "message": "Image not found."})
+	c.Header("ETag", image.ETag())
+	if notModified(c.Request, image.ETag(), time.Time{}) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"image": image})
 }
 
-// UpdateImageByID updates an existing image record.
+// UpdateImageByID updates an existing image record. The If-Match header must
+// carry the ETag of the version being edited, so concurrent edits cannot
+// silently overwrite each other.
 func (h *ImageHandler) UpdateImageByID(c *gin.Context) {
 	imageId := c.Param("image_id")
 	if imageId == "" {
@@ -45,50 +72,190 @@ func (h *ImageHandler) UpdateImageByID(c *gin.Context) {
 		return
 	}
 
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "precondition_required", "message": "If-Match must carry the ETag of the image being updated."})
+		return
+	}
+	version, ok := models.ParseImageETag(ifMatch)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition_failed", "message": "If-Match does not match the image's ETag."})
+		return
+	}
+
 	var updateRequest models.ImageUpdateRequest
 	if err := c.ShouldBindJSON(&updateRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
 		return
 	}
 
-	image, err := h.imageService.UpdateImage(c.Request.Context(), imageId, &updateRequest)
+	image, err := h.imageService.UpdateImage(c.Request.Context(), imageId, version, &updateRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "image_update_error", "message": err.Error()})
+		respondError(c, err, "image_update_error")
 		return
 	}
+	c.Header("ETag", image.ETag())
 
 	c.JSON(http.StatusOK, gin.H{"message": "Image updated successfully", "image": image})
 }
 
+// BatchUpdateImages applies one label update to several images.
+func (h *ImageHandler) BatchUpdateImages(c *gin.Context) {
+	var batchRequest models.ImageBatchUpdateRequest
+	if err := c.ShouldBindJSON(&batchRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	response, err := h.imageService.BatchUpdateImages(c.Request.Context(), &batchRequest)
+	if err != nil {
+		respondError(c, err, "image_batch_update_error")
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// maxManifestBytes bounds the body of a manifest import.
+const maxManifestBytes = 64 << 20
+
+// ImportLabels sets labels from a CSV or JSON Lines manifest in the request
+// body. The format is taken from the format parameter, or else from the
+// Content-Type; rows are matched by match_by, file_uid by default.
+func (h *ImageHandler) ImportLabels(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = manifest.FormatCSV
+		switch c.ContentType() {
+		case "application/x-ndjson", "application/jsonl":
+			format = manifest.FormatJSONL
+		}
+	}
+	importRequest := &models.ImportRequest{MatchBy: c.DefaultQuery("match_by", models.ImportMatchFileUID)}
+	if importRequest.MatchBy != models.ImportMatchFileUID && importRequest.MatchBy != models.ImportMatchFileName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "match_by must be file_uid or file_name."})
+		return
+	}
+	if dryRun := c.Query("dry_run"); dryRun != "" {
+		var err error
+		if importRequest.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "dry_run must be true or false."})
+			return
+		}
+	}
+
+	rows, err := manifest.Read(http.MaxBytesReader(c.Writer, c.Request.Body, maxManifestBytes), format, importRequest.MatchBy)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "manifest_too_large", "message": fmt.Sprintf("Manifests may be at most %d bytes.", maxManifestBytes)})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "image_import_error")
+		return
+	}
+	importRequest.Rows = rows
+
+	report, err := h.imageService.ImportLabels(c.Request.Context(), importRequest)
+	if err != nil {
+		respondError(c, err, "image_import_error")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // DeleteImageByID deletes an image record and its associated files.
 func (h *ImageHandler) DeleteImageByID(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID") // Get user ID from Auth-Service
-	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id_missing", "message": "User ID not found in request headers."})
+	principal, ok := auth.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated", "message": "Valid credentials are required."})
 		return
 	}
 
-	role := c.GetHeader("X-User-Role") // Get user role from Auth-Service
-	if role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "You do not have permission to perform this action."})
+	imageId := c.Param("image_id")
+	if imageId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image_id_missing", "message": "Image ID is required."})
+		return
+	}
+	err := h.imageService.DeleteImage(c.Request.Context(), imageId, principal.UserID)
+	if err != nil {
+		respondError(c, err, "image_deletion_error")
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image moved to trash"})
+}
 
+// RestoreImageByID moves an image record from the trash back to the catalog.
+func (h *ImageHandler) RestoreImageByID(c *gin.Context) {
 	imageId := c.Param("image_id")
 	if imageId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "image_id_missing", "message": "Image ID is required."})
 		return
 	}
-	err := h.imageService.DeleteImage(c.Request.Context(), imageId)
+	image, err := h.imageService.RestoreImage(c.Request.Context(), imageId)
+	if err != nil {
+		respondError(c, err, "image_restore_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image restored successfully", "image": image})
+}
+
+// GetDeletedImages retrieves a page of images in the trash.
+func (h *ImageHandler) GetDeletedImages(c *gin.Context) {
+	filter, ok := parseImageFilter(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.imageService.ListDeletedImages(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "image_deletion_error", "message": err.Error()})
+		respondError(c, err, "image_retrieval_error")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+	c.JSON(http.StatusOK, list)
 }
 
-// GetImages retrieves a list of images with optional filtering.
+// GetImages retrieves a page of images with optional filtering and sorting.
+// An empty page is not an error: it is returned with no images and no
+// next_page_token.
 func (h *ImageHandler) GetImages(c *gin.Context) {
+	filter, ok := parseImageFilter(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.imageService.ListImages(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err, "image_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetImageFacets counts the images matching the filter by the values of the
+// fields listed in the fields query parameter.
+func (h *ImageHandler) GetImageFacets(c *gin.Context) {
+	fields, err := models.ParseFacetFields(c.Query("fields"))
+	if err != nil {
+		respondError(c, err, "invalid_fields")
+		return
+	}
+	filter, ok := parseImageFilter(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.imageService.ImageFacets(c.Request.Context(), filter, fields)
+	if err != nil {
+		respondError(c, err, "facet_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// parseImageFilter reads the filter, pagination and sorting query parameters.
+// It writes a 400 response and returns false if they are malformed; their
+// values are validated by the service.
+func parseImageFilter(c *gin.Context) (*models.ImageFilter, bool) {
 	datasetName := c.Query("dataset_name")
 	organType := c.Query("organ_type")
 	diseaseType := c.Query("disease_type")
@@ -103,16 +270,18 @@ func (h *ImageHandler) GetImages(c *gin.Context) {
 		Classification: &classification,
 		SubType:        &subtype,
 		Grade:          &grade,
+		PageToken:      c.Query("page_token"),
+		SortBy:         c.Query("sort_by"),
+		Order:          c.Query("order"),
 	}
 
-	images, err := h.imageService.ListImages(c.Request.Context(), filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "image_retrieval_error", "message": err.Error()})
-		return
-	}
-	if len(images) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "No images found."})
-		return
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_pagination", "message": "limit must be an integer."})
+			return nil, false
+		}
+		filter.Limit = n
 	}
-	c.JSON(http.StatusOK, gin.H{"images": images})
+	return filter, true
 }
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/adapter"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
+)
+
+var (
+	testAdmin  = &auth.Principal{UserID: "admin", Role: auth.RoleAdmin}
+	testReader = &auth.Principal{UserID: "reader", Role: auth.RoleViewer}
+	testNobody = &auth.Principal{UserID: "nobody", Role: auth.RoleViewer}
+)
+
+// testEnv wires the handlers to the in-memory repositories and a local
+// object store in a temporary directory. testReader may read the "breast"
+// dataset.
+type testEnv struct {
+	cfg    *config.Config
+	images *adapter.MemoryImageRepository
+	store  *adapter.LocalObjectStore
+	tiles  *tilecache.Cache
+	access *service.AccessService
+	svc    *service.ImageService
+}
+
+func newTestEnv(t *testing.T, images ...*models.Image) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store, err := adapter.NewLocalObjectStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Storage: config.StorageConfig{DeleteConcurrency: 1, DeleteMaxAttempts: 1},
+		Proxy: config.ProxyConfig{
+			SigningKey:    "0123456789abcdef0123456789abcdef",
+			SignedURLTTL:  time.Hour,
+			PublicBaseURL: "https://catalog.example.org",
+		},
+		Render: config.RenderConfig{MaxWidth: 2000, MaxHeight: 2000, MaxPixels: 1 << 22, JPEGQuality: 90, TileConcurrency: 2},
+	}
+
+	auditRepo := adapter.NewMemoryAuditRepository()
+	imageRepo := adapter.NewMemoryImageRepository(auditRepo, images...)
+	annotations := adapter.NewMemoryAnnotationRepository()
+	access := service.NewAccessService(adapter.NewMemoryACLRepository(
+		&models.DatasetGrant{DatasetName: "breast", Subject: models.UserSubject(testReader.UserID), Permission: models.PermissionRead},
+	))
+	tiles := tilecache.New(0, 0, 1<<20, tilecache.NewMemoryTier(1<<24))
+	taxonomies := service.NewTaxonomyService(adapter.NewMemoryTaxonomyRepository())
+	audit := service.NewAuditService(auditRepo, imageRepo, access)
+
+	return &testEnv{
+		cfg:    cfg,
+		images: imageRepo,
+		store:  store,
+		tiles:  tiles,
+		access: access,
+		svc:    service.NewImageService(imageRepo, annotations, audit, store, access, taxonomies, tiles, cfg),
+	}
+}
+
+// serve routes a single request to handler, registered at pattern, as the
+// given principal.
+func serve(handler gin.HandlerFunc, method, pattern, target string, principal *auth.Principal, body string, headers ...string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(method, pattern, func(c *gin.Context) {
+		if principal != nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		}
+		c.Next()
+	}, handler)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testImages returns n images of the "breast" dataset, created a minute apart.
+func testImages(n int) []*models.Image {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	images := make([]*models.Image, n)
+	for i := range images {
+		images[i] = &models.Image{
+			ID:          fmt.Sprintf("img-%02d", i),
+			FileUID:     fmt.Sprintf("uid-%02d", i),
+			FileName:    fmt.Sprintf("slide-%02d.svs", n-i),
+			DatasetName: "breast",
+			OrganType:   "breast",
+			Width:       1000 + i,
+			Height:      600,
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+			Version:     1,
+		}
+	}
+	return images
+}
+
+func TestGetImagesPaging(t *testing.T) {
+	env := newTestEnv(t, testImages(5)...)
+	h := NewImageHandler(env.svc)
+
+	var ids []string
+	token := ""
+	for page := 0; ; page++ {
+		query := url.Values{"limit": {"2"}, "sort_by": {models.SortByWidth}, "order": {models.OrderAsc}}
+		if token != "" {
+			query.Set("page_token", token)
+		}
+		w := serve(h.GetImages, http.MethodGet, "/images", "/images?"+query.Encode(), testReader, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: status = %d, body %s", page, w.Code, w.Body)
+		}
+		var list models.ImageList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatal(err)
+		}
+		if list.TotalCount != 5 {
+			t.Errorf("page %d: total_count = %d, want 5", page, list.TotalCount)
+		}
+		for _, image := range list.Images {
+			ids = append(ids, image.ID)
+		}
+		if token = list.NextPageToken; token == "" {
+			break
+		}
+		if page > 5 {
+			t.Fatal("paging does not terminate")
+		}
+	}
+	if got := strings.Join(ids, ","); got != "img-00,img-01,img-02,img-03,img-04" {
+		t.Errorf("paged IDs = %s", got)
+	}
+}
+
+func TestGetImagesEmptyPage(t *testing.T) {
+	env := newTestEnv(t, testImages(3)...)
+	h := NewImageHandler(env.svc)
+
+	tests := []struct {
+		name      string
+		target    string
+		principal *auth.Principal
+	}{
+		{name: "no match", target: "/images?organ_type=lung", principal: testReader},
+		{name: "dataset not granted", target: "/images?dataset_name=colon", principal: testReader},
+		{name: "no readable datasets", target: "/images", principal: testNobody},
+		{name: "admin no match", target: "/images?grade=3", principal: testAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.GetImages, http.MethodGet, "/images", tt.target, tt.principal, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if string(body["images"]) != "[]" || string(body["total_count"]) != "0" {
+				t.Errorf("body = %s, want no images and a total_count of 0", w.Body)
+			}
+			if _, ok := body["next_page_token"]; ok {
+				t.Errorf("body = %s, want no next_page_token", w.Body)
+			}
+		})
+	}
+}
+
+func TestGetImagesInvalidPagination(t *testing.T) {
+	env := newTestEnv(t, testImages(1)...)
+	h := NewImageHandler(env.svc)
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{target: "/images?limit=abc", status: http.StatusBadRequest},
+		{target: "/images?limit=5000", status: http.StatusUnprocessableEntity},
+		{target: "/images?sort_by=grade", status: http.StatusUnprocessableEntity},
+		{target: "/images?page_token=garbage", status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(h.GetImages, http.MethodGet, "/images", tt.target, testAdmin, "")
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestGetImageByID(t *testing.T) {
+	images := append(testImages(1), &models.Image{ID: "colon-01", FileUID: "uid-colon", DatasetName: "colon", Version: 4})
+	env := newTestEnv(t, images...)
+	h := NewImageHandler(env.svc)
+
+	tests := []struct {
+		name      string
+		id        string
+		principal *auth.Principal
+		headers   []string
+		status    int
+		etag      string
+	}{
+		{name: "readable", id: "img-00", principal: testReader, status: http.StatusOK, etag: `"1"`},
+		{name: "admin", id: "colon-01", principal: testAdmin, status: http.StatusOK, etag: `"4"`},
+		{name: "not modified", id: "img-00", principal: testReader, headers: []string{"If-None-Match", `"1"`}, status: http.StatusNotModified, etag: `"1"`},
+		{name: "modified", id: "img-00", principal: testReader, headers: []string{"If-None-Match", `"0"`}, status: http.StatusOK, etag: `"1"`},
+		{name: "dataset not granted", id: "colon-01", principal: testReader, status: http.StatusForbidden},
+		{name: "missing", id: "img-99", principal: testAdmin, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.GetImageByID, http.MethodGet, "/images/:image_id", "/images/"+tt.id, tt.principal, "", tt.headers...)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Image models.Image `json:"image"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Image.ID != tt.id {
+				t.Errorf("image ID = %q, want %q", body.Image.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestDeleteImageByID(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		principal *auth.Principal
+		status    int
+		deleted   bool
+	}{
+		{name: "admin", id: "img-00", principal: testAdmin, status: http.StatusOK, deleted: true},
+		{name: "reader", id: "img-00", principal: testReader, status: http.StatusForbidden},
+		{name: "unauthenticated", id: "img-00", status: http.StatusUnauthorized},
+		{name: "missing", id: "img-99", principal: testAdmin, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testImages(1)...)
+			h := NewImageHandler(env.svc)
+
+			w := serve(h.DeleteImageByID, http.MethodDelete, "/images/:image_id", "/images/"+tt.id, tt.principal, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+
+			w = serve(h.GetImageByID, http.MethodGet, "/images/:image_id", "/images/img-00", testAdmin, "")
+			if deleted := w.Code == http.StatusNotFound; deleted != tt.deleted {
+				t.Errorf("GET after delete: status = %d, want the image deleted = %v", w.Code, tt.deleted)
+			}
+			w = serve(h.GetDeletedImages, http.MethodGet, "/images/trash", "/images/trash", testAdmin, "")
+			if inTrash := strings.Contains(w.Body.String(), `"img-00"`); inTrash != tt.deleted {
+				t.Errorf("trash = %s, want the image in it = %v", w.Body, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestUpdateImageByIDIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		body    string
+		status  int
+		etag    string
+	}{
+		{name: "current version", ifMatch: `"1"`, body: `{"grade":"2"}`, status: http.StatusOK, etag: `"2"`},
+		{name: "missing", body: `{"grade":"2"}`, status: http.StatusPreconditionRequired},
+		{name: "any", ifMatch: "*", body: `{"grade":"2"}`, status: http.StatusPreconditionRequired},
+		{name: "stale version", ifMatch: `"0"`, body: `{"grade":"2"}`, status: http.StatusPreconditionFailed},
+		{name: "weak", ifMatch: `W/"1"`, body: `{"grade":"2"}`, status: http.StatusPreconditionFailed},
+		{name: "unquoted", ifMatch: "1", body: `{"grade":"2"}`, status: http.StatusPreconditionFailed},
+		{name: "invalid body", ifMatch: `"1"`, body: `{"grade":`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, testImages(1)...)
+			h := NewImageHandler(env.svc)
+
+			var headers []string
+			if tt.ifMatch != "" {
+				headers = append(headers, "If-Match", tt.ifMatch)
+			}
+			w := serve(h.UpdateImageByID, http.MethodPut, "/images/:image_id", "/images/img-00", testAdmin, tt.body, headers...)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+
+			image, err := env.images.Read(context.Background(), "img-00")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated := image.Grade != nil; updated != (tt.status == http.StatusOK) {
+				t.Errorf("stored grade = %v, want it updated = %v", image.Grade, tt.status == http.StatusOK)
+			}
+		})
+	}
+}
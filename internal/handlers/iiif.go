@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+	"github.com/histopathai/image-catalog-service/internal/iiif"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+// IIIFHandler serves catalogued images through the IIIF Image API 3.0,
+// rendering them from their Deep Zoom tile pyramids.
+type IIIFHandler struct {
+	imageService  *service.ImageService
+	renderer      *service.TileRenderer
+	limits        iiif.Limits
+	cacheControl  string
+	publicBaseURL string // Origin of service IDs; the request's Host if empty
+}
+
+func NewIIIFHandler(imageService *service.ImageService, renderer *service.TileRenderer, cfg *config.Config) *IIIFHandler {
+	return &IIIFHandler{
+		imageService:  imageService,
+		renderer:      renderer,
+		limits:        iiif.Limits{MaxWidth: cfg.Render.MaxWidth, MaxHeight: cfg.Render.MaxHeight},
+		cacheControl:  cfg.Proxy.CacheControlTile,
+		publicBaseURL: cfg.Proxy.PublicBaseURL,
+	}
+}
+
+// RedirectToInfo redirects the base URI of an image to its info.json.
+func (h *IIIFHandler) RedirectToInfo(c *gin.Context) {
+	c.Redirect(http.StatusSeeOther, h.serviceID(c, c.Param("image_id"))+"/info.json")
+}
+
+// GetInfo returns the info.json document of an image.
+func (h *IIIFHandler) GetInfo(c *gin.Context) {
+	imageID := c.Param("image_id")
+	_, pyramid, ok := h.imagePyramid(c, imageID)
+	if !ok {
+		return
+	}
+
+	contentType := gin.MIMEJSON
+	if strings.Contains(c.GetHeader("Accept"), "application/ld+json") {
+		contentType = `application/ld+json;profile="` + iiif.Context + `"`
+	}
+	data, _ := json.Marshal(iiif.NewInfo(h.serviceID(c, imageID), pyramid, h.limits))
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Link", `<`+iiif.ProfileURI+`>;rel="profile"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetImage renders a region of an image at the requested size, rotation,
+// quality and format. Requests for exactly one stored tile are answered
+// from that tile alone.
+func (h *IIIFHandler) GetImage(c *gin.Context) {
+	img, pyramid, ok := h.imagePyramid(c, c.Param("image_id"))
+	if !ok {
+		return
+	}
+
+	req, err := iiif.ParseRequest(c.Param("region"), c.Param("size"), c.Param("rotation"), c.Param("quality_format"), pyramid.Width, pyramid.Height, h.limits)
+	if err != nil {
+		respondIIIFError(c, err)
+		return
+	}
+	format := service.FormatPNG
+	if req.Format == iiif.FormatJPG {
+		format = service.FormatJPEG
+	}
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Link", `<`+iiif.ProfileURI+`>;rel="profile"`)
+	c.Header("Cache-Control", h.cacheControl)
+
+	// A request for one tile's area is cropped from that tile, or answered
+	// with the stored tile itself if there is nothing to crop or convert.
+	tile, ok := iiif.StoredTile(pyramid, req)
+	if ok && tile.Verbatim {
+		entry, err := h.renderer.ReadObject(c.Request.Context(), service.TileObject(img, pyramid, tile.Level, tile.Column, tile.Row))
+		if err != nil {
+			respondIIIFError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, service.ContentType(format), entry.Data)
+		return
+	}
+
+	var rendered *image.RGBA
+	if ok {
+		rendered, err = h.renderer.Compose(c.Request.Context(), img, pyramid, tile.Level, tile.Region)
+	} else {
+		rendered, err = h.renderer.Render(c.Request.Context(), img, pyramid, req.Region, req.Width, req.Height)
+	}
+	if err != nil {
+		respondIIIFError(c, err)
+		return
+	}
+	var body bytes.Buffer
+	if err := h.renderer.Encode(&body, iiif.Apply(rendered, req), format); err != nil {
+		respondIIIFError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, service.ContentType(format), body.Bytes())
+}
+
+// imagePyramid reads an image the caller may view and its tile pyramid,
+// writing the error response if either is unavailable.
+func (h *IIIFHandler) imagePyramid(c *gin.Context, imageID string) (*models.Image, *deepzoom.Pyramid, bool) {
+	image, err := h.imageService.GetImage(c.Request.Context(), imageID)
+	if err != nil {
+		respondIIIFError(c, err)
+		return nil, nil, false
+	}
+	pyramid, err := deepzoom.ForImage(image)
+	if err != nil {
+		respondIIIFError(c, err)
+		return nil, nil, false
+	}
+	return image, pyramid, true
+}
+
+// respondIIIFError writes the statuses the IIIF Image API prescribes: 400 for
+// invalid requests and 501 for unsupported features. Other errors are mapped
+// as elsewhere in the API.
+func respondIIIFError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, iiif.ErrUnsupported):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "not_implemented", "message": err.Error()})
+	case errors.Is(err, models.ErrValidation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+	default:
+		respondError(c, err, "iiif_error")
+	}
+}
+
+// serviceID returns the absolute base URI of an image's IIIF service. It is
+// built on the configured public base URL; without one, on the Host the
+// request was sent to. X-Forwarded-* headers are ignored, as any client can
+// set them.
+func (h *IIIFHandler) serviceID(c *gin.Context, imageID string) string {
+	base := h.publicBaseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/iiif/3/" + url.PathEscape(imageID)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+var (
+	overlapColor = color.RGBA{R: 255, A: 255}
+	tileColor    = color.RGBA{G: 255, A: 255}
+)
+
+// writeTile stores a PNG tile of width by height pixels whose first
+// overlapColumns columns are overlapColor and the rest tileColor.
+func writeTile(t *testing.T, env *testEnv, name string, width, height, overlapColumns int) []byte {
+	t.Helper()
+	tile := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < overlapColumns {
+				tile.SetRGBA(x, y, overlapColor)
+			} else {
+				tile.SetRGBA(x, y, tileColor)
+			}
+		}
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, tile); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.store.Write(context.Background(), name, "image/png", bytes.NewReader(data.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+func TestGetIIIFImageStoredTile(t *testing.T) {
+	overlap, noOverlap := 1, 0
+	images := []*models.Image{
+		{ID: "overlap", DatasetName: "breast", Width: 508, Height: 254, Format: "png", TileSize: 254, Overlap: &overlap, TilesGCSPath: "gs://bucket/overlap/"},
+		{ID: "flush", DatasetName: "breast", Width: 508, Height: 254, Format: "png", TileSize: 254, Overlap: &noOverlap, TilesGCSPath: "gs://bucket/flush/"},
+	}
+	env := newTestEnv(t, images...)
+	h := NewIIIFHandler(env.svc, service.NewTileRenderer(env.store, env.tiles, env.cfg.Render), env.cfg)
+
+	// The second tile of the top level reaches one pixel into the first.
+	writeTile(t, env, "overlap/9/1_0.png", 255, 254, 1)
+	stored := writeTile(t, env, "flush/9/1_0.png", 254, 254, 0)
+
+	tests := []struct {
+		name     string
+		target   string
+		verbatim bool
+	}{
+		{name: "overlap cropped", target: "/iiif/3/overlap/254,0,254,254/max/0/default.png"},
+		{name: "overlap cropped at the tile size", target: "/iiif/3/overlap/254,0,254,254/254,254/0/default.png"},
+		{name: "no overlap", target: "/iiif/3/flush/254,0,254,254/max/0/default.png", verbatim: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.GetImage, http.MethodGet, "/iiif/3/:image_id/:region/:size/:rotation/:quality_format", tt.target, testAdmin, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			if tt.verbatim && !bytes.Equal(w.Body.Bytes(), stored) {
+				t.Error("body is not the stored tile")
+			}
+			got, err := png.Decode(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Bounds().Dx() != 254 || got.Bounds().Dy() != 254 {
+				t.Fatalf("size = %v, want 254x254", got.Bounds().Size())
+			}
+			for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+				for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+					if c := color.RGBAModel.Convert(got.At(x, y)); c != tileColor {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, c, tileColor)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errRangeNotSatisfiable = errors.New("range not satisfiable")
+	errMultipleRanges      = errors.New("multiple ranges are not supported")
+)
+
+// byteRange is a single satisfiable byte range of an object.
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (r *byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// requestedRange returns the byte range to serve for the request, or nil if
+// the whole object should be sent. A Range header in another unit, a
+// malformed one, or one whose If-Range precondition fails is ignored as RFC
+// 9110 allows. Multiple ranges are rejected because multipart/byteranges
+// responses are not supported.
+func requestedRange(r *http.Request, size int64, etag string, lastModified time.Time) (*byteRange, error) {
+	header := r.Header.Get("Range")
+	if header == "" || !ifRangeMatches(r.Header.Get("If-Range"), etag, lastModified) {
+		return nil, nil
+	}
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, nil
+	}
+	if strings.Contains(spec, ",") {
+		return nil, errMultipleRanges
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	if first == "" {
+		// Suffix range: the last N bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		n = min(n, size)
+		return &byteRange{start: size - n, length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+	return &byteRange{start: start, length: end - start + 1}, nil
+}
+
+// ifRangeMatches evaluates an If-Range header: a strong entity tag must
+// match exactly, and an HTTP date must equal the last modification time.
+func ifRangeMatches(ifRange, etag string, lastModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == etag
+	}
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+	date, err := http.ParseTime(ifRange)
+	return err == nil && lastModified.Truncate(time.Second).Equal(date)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestedRange(t *testing.T) {
+	const etag = `"v1"`
+	lastModified := time.Date(2026, 3, 4, 5, 6, 7, 800, time.UTC)
+
+	tests := []struct {
+		name    string
+		rng     string
+		ifRange string
+		size    int64
+		want    *byteRange
+		wantErr error
+	}{
+		{name: "no range", size: 100},
+		{name: "closed", rng: "bytes=10-19", size: 100, want: &byteRange{start: 10, length: 10}},
+		{name: "open ended", rng: "bytes=90-", size: 100, want: &byteRange{start: 90, length: 10}},
+		{name: "end past size", rng: "bytes=90-500", size: 100, want: &byteRange{start: 90, length: 10}},
+		{name: "single byte", rng: "bytes=0-0", size: 100, want: &byteRange{start: 0, length: 1}},
+		{name: "suffix", rng: "bytes=-10", size: 100, want: &byteRange{start: 90, length: 10}},
+		{name: "suffix longer than object", rng: "bytes=-500", size: 100, want: &byteRange{start: 0, length: 100}},
+		{name: "start past size", rng: "bytes=100-", size: 100, wantErr: errRangeNotSatisfiable},
+		{name: "empty suffix", rng: "bytes=-0", size: 100, wantErr: errRangeNotSatisfiable},
+		{name: "suffix of empty object", rng: "bytes=-10", size: 0, wantErr: errRangeNotSatisfiable},
+		{name: "multiple", rng: "bytes=0-9,20-29", size: 100, wantErr: errMultipleRanges},
+		{name: "other unit", rng: "items=0-9", size: 100},
+		{name: "no dash", rng: "bytes=10", size: 100},
+		{name: "end before start", rng: "bytes=20-10", size: 100},
+		{name: "not a number", rng: "bytes=a-9", size: 100},
+		{name: "if-range etag matches", rng: "bytes=0-9", ifRange: etag, size: 100, want: &byteRange{start: 0, length: 10}},
+		{name: "if-range etag differs", rng: "bytes=0-9", ifRange: `"v2"`, size: 100},
+		{name: "if-range weak etag", rng: "bytes=0-9", ifRange: `W/"v1"`, size: 100},
+		{name: "if-range date matches", rng: "bytes=0-9", ifRange: lastModified.Format(http.TimeFormat), size: 100, want: &byteRange{start: 0, length: 10}},
+		{name: "if-range date differs", rng: "bytes=0-9", ifRange: lastModified.Add(-time.Second).Format(http.TimeFormat), size: 100},
+		{name: "if-range invalid", rng: "bytes=0-9", ifRange: "yesterday", size: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.rng != "" {
+				req.Header.Set("Range", tt.rng)
+			}
+			if tt.ifRange != "" {
+				req.Header.Set("If-Range", tt.ifRange)
+			}
+
+			got, err := requestedRange(req, tt.size, etag, lastModified)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("requestedRange() error = %v, want %v", err, tt.wantErr)
+			}
+			switch {
+			case got == nil && tt.want != nil:
+				t.Errorf("requestedRange() = nil, want %+v", *tt.want)
+			case got != nil && tt.want == nil:
+				t.Errorf("requestedRange() = %+v, want nil", *got)
+			case got != nil && *got != *tt.want:
+				t.Errorf("requestedRange() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyObjectRange(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Proxy.AllowedPrefixes = []string{"public"}
+	h := NewGCSProxyHandler(env.store, env.svc, env.tiles, env.cfg.Proxy)
+
+	// The small object is served from the tile cache and the large one,
+	// which exceeds its maximum object size, is streamed from the store.
+	small := bytes.Repeat([]byte("0123456789"), 10)
+	large := bytes.Repeat([]byte("0123456789"), 1<<17)
+	for name, data := range map[string][]byte{"public/small.bin": small, "public/large.bin": large} {
+		if err := env.store.Write(context.Background(), name, "application/octet-stream", bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		object       string
+		rng          string
+		status       int
+		contentRange string
+		body         []byte
+	}{
+		{name: "small whole", object: "public/small.bin", status: http.StatusOK, body: small},
+		{name: "small range", object: "public/small.bin", rng: "bytes=5-14", status: http.StatusPartialContent, contentRange: "bytes 5-14/100", body: small[5:15]},
+		{name: "small suffix", object: "public/small.bin", rng: "bytes=-3", status: http.StatusPartialContent, contentRange: "bytes 97-99/100", body: small[97:]},
+		{name: "small unsatisfiable", object: "public/small.bin", rng: "bytes=100-", status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */100"},
+		{name: "large range", object: "public/large.bin", rng: "bytes=1048570-", status: http.StatusPartialContent, contentRange: "bytes 1048570-1310719/1310720", body: large[1048570:]},
+		{name: "large multiple ranges", object: "public/large.bin", rng: "bytes=0-1,4-5", status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */1310720"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.rng != "" {
+				headers = append(headers, "Range", tt.rng)
+			}
+			w := serve(h.ProxyObject, http.MethodGet, "/proxy/*objectPath", "/proxy/"+tt.object, testReader, "", headers...)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if tt.body != nil && !bytes.Equal(w.Body.Bytes(), tt.body) {
+				t.Errorf("body has %d bytes, want %d", w.Body.Len(), len(tt.body))
+			}
+		})
+	}
+}
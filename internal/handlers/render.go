@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+// minThumbnailSize is the smallest thumbnail that may be requested.
+const minThumbnailSize = 16
+
+// thumbnailFormats maps the media types a thumbnail can be returned as to
+// renderer output formats.
+var thumbnailFormats = map[string]string{
+	"image/jpeg": service.FormatJPEG,
+	"image/webp": service.FormatWebP,
+	"image/png":  service.FormatPNG,
+}
+
+// RenderHandler serves images composed from the Deep Zoom tiles of an image.
+type RenderHandler struct {
+	imageService          *service.ImageService
+	renderer              *service.TileRenderer
+	maxWidth              int
+	maxHeight             int
+	maxPixels             int
+	cacheControlThumbnail string
+}
+
+func NewRenderHandler(imageService *service.ImageService, renderer *service.TileRenderer, cfg *config.Config) *RenderHandler {
+	return &RenderHandler{
+		imageService:          imageService,
+		renderer:              renderer,
+		maxWidth:              cfg.Render.MaxWidth,
+		maxHeight:             cfg.Render.MaxHeight,
+		maxPixels:             cfg.Render.MaxPixels,
+		cacheControlThumbnail: cfg.Proxy.CacheControlThumbnail,
+	}
+}
+
+// GetThumbnail returns a thumbnail of an image whose longest side is size
+// pixels. The format is negotiated from the Accept header among JPEG, WebP
+// and PNG, defaulting to JPEG. Generated thumbnails are stored next to the
+// image's other derived objects and reused.
+func (h *RenderHandler) GetThumbnail(c *gin.Context) {
+	maxSize := min(h.maxWidth, h.maxHeight)
+	size, err := strconv.Atoi(c.Query("size"))
+	if err != nil || size < minThumbnailSize || size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_size", "message": fmt.Sprintf("size must be an integer between %d and %d.", minThumbnailSize, maxSize)})
+		return
+	}
+
+	c.Header("Vary", "Accept")
+	mediaType := c.NegotiateFormat("image/jpeg", "image/webp", "image/png")
+	if mediaType == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "not_acceptable", "message": "Thumbnails are available as image/jpeg, image/webp or image/png."})
+		return
+	}
+	format := thumbnailFormats[mediaType]
+
+	img, err := h.imageService.GetImage(c.Request.Context(), c.Param("image_id"))
+	if err != nil {
+		respondError(c, err, "image_retrieval_error")
+		return
+	}
+	pyramid, err := deepzoom.ForImage(img)
+	if err != nil {
+		respondError(c, err, "thumbnail_error")
+		return
+	}
+
+	entry, err := h.renderer.Thumbnail(c.Request.Context(), img, pyramid, size, format)
+	if err != nil {
+		respondError(c, err, "thumbnail_error")
+		return
+	}
+
+	c.Header("ETag", entry.ETag)
+	c.Header("Last-Modified", entry.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", h.cacheControlThumbnail)
+	if notModified(c.Request, entry.ETag, entry.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_thumbnail_%d.%s", img.ID, size, format)
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	c.Data(http.StatusOK, service.ContentType(format), entry.Data)
+}
+
+// GetRegion assembles a region of one pyramid level into a single PNG or
+// JPEG image. x, y, w and h are pixels of the level, which defaults to the
+// full resolution one; the region is cropped to the level's bounds. Invalid
+// parameters, malformed or out of range, are validation errors (422).
+func (h *RenderHandler) GetRegion(c *gin.Context) {
+	params := map[string]int{"level": -1}
+	for _, name := range []string{"x", "y", "w", "h", "level"} {
+		value := c.Query(name)
+		if value == "" {
+			if name == "level" {
+				continue
+			}
+			respondError(c, models.NewError(models.ErrValidation, "%s is required", name), "region_error")
+			return
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(c, models.NewError(models.ErrValidation, "%s must be an integer", name), "region_error")
+			return
+		}
+		params[name] = n
+	}
+
+	format := c.DefaultQuery("format", service.FormatPNG)
+	if format == "jpg" {
+		format = service.FormatJPEG
+	}
+	if format != service.FormatPNG && format != service.FormatJPEG {
+		respondError(c, models.NewError(models.ErrValidation, "format must be \"png\" or \"jpeg\""), "region_error")
+		return
+	}
+
+	img, err := h.imageService.GetImage(c.Request.Context(), c.Param("image_id"))
+	if err != nil {
+		respondError(c, err, "image_retrieval_error")
+		return
+	}
+	pyramid, err := deepzoom.ForImage(img)
+	if err != nil {
+		respondError(c, err, "region_error")
+		return
+	}
+
+	level := params["level"]
+	if level < 0 {
+		level = pyramid.MaxLevel()
+	}
+	region, err := h.levelRegion(pyramid, level, params["x"], params["y"], params["w"], params["h"])
+	if err != nil {
+		respondError(c, err, "region_error")
+		return
+	}
+
+	composed, err := h.renderer.Compose(c.Request.Context(), img, pyramid, level, region)
+	if err != nil {
+		respondError(c, err, "region_error")
+		return
+	}
+
+	filename := fmt.Sprintf("%s_L%d_%d_%d_%d_%d.%s", img.ID, level, region.Min.X, region.Min.Y, region.Dx(), region.Dy(), format)
+	c.Header("Content-Type", service.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := h.renderer.Encode(flushWriter{c.Writer}, composed, format); err != nil {
+		// The status has been sent; all that is left is to log the failure.
+		slog.Warn("Failed to encode region", "image_id", img.ID, "error", err)
+	}
+}
+
+// levelRegion validates a requested region of a level and crops it to the level.
+func (h *RenderHandler) levelRegion(pyramid *deepzoom.Pyramid, level, x, y, width, height int) (image.Rectangle, error) {
+	if !pyramid.ValidLevel(level) {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "level must be between 0 and %d", pyramid.MaxLevel())
+	}
+	if x < 0 || y < 0 || width <= 0 || height <= 0 {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "x and y must not be negative and w and h must be positive")
+	}
+
+	levelWidth, levelHeight := pyramid.LevelSize(level)
+	region := image.Rect(x, y, x+width, y+height).Intersect(image.Rect(0, 0, levelWidth, levelHeight))
+	if region.Empty() {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "region lies outside level %d, which is %dx%d pixels", level, levelWidth, levelHeight)
+	}
+	if region.Dx() > h.maxWidth || region.Dy() > h.maxHeight {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "region is %dx%d pixels; the maximum is %dx%d, use a lower level", region.Dx(), region.Dy(), h.maxWidth, h.maxHeight)
+	}
+	if region.Dx()*region.Dy() > h.maxPixels {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "region has %d pixels; the maximum is %d, use a lower level", region.Dx()*region.Dy(), h.maxPixels)
+	}
+	return region, nil
+}
+
+// flushWriter sends every write to the client immediately, so an encoded
+// image is streamed as the encoder produces it instead of being held in the
+// server's response buffer.
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.w.Flush()
+	return n, err
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/service"
+)
+
+type TaxonomyHandler struct {
+	taxonomyService *service.TaxonomyService
+}
+
+func NewTaxonomyHandler(taxonomyService *service.TaxonomyService) *TaxonomyHandler {
+	return &TaxonomyHandler{
+		taxonomyService: taxonomyService,
+	}
+}
+
+// GetTaxonomies lists the taxonomies of all organ types.
+func (h *TaxonomyHandler) GetTaxonomies(c *gin.Context) {
+	taxonomies, err := h.taxonomyService.ListTaxonomies(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "taxonomy_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"taxonomies": taxonomies})
+}
+
+// GetTaxonomy returns the taxonomy of an organ type.
+func (h *TaxonomyHandler) GetTaxonomy(c *gin.Context) {
+	taxonomy, err := h.taxonomyService.GetTaxonomy(c.Request.Context(), c.Param("organ_type"))
+	if err != nil {
+		respondError(c, err, "taxonomy_retrieval_error")
+		return
+	}
+	c.JSON(http.StatusOK, taxonomy)
+}
+
+// PutTaxonomy creates or replaces the taxonomy of an organ type.
+func (h *TaxonomyHandler) PutTaxonomy(c *gin.Context) {
+	var taxonomyRequest models.TaxonomyRequest
+	if err := c.ShouldBindJSON(&taxonomyRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": "Invalid request body."})
+		return
+	}
+
+	taxonomy, err := h.taxonomyService.PutTaxonomy(c.Request.Context(), c.Param("organ_type"), &taxonomyRequest)
+	if err != nil {
+		respondError(c, err, "taxonomy_update_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Taxonomy saved successfully", "taxonomy": taxonomy})
+}
+
+// DeleteTaxonomy removes the taxonomy of an organ type.
+func (h *TaxonomyHandler) DeleteTaxonomy(c *gin.Context) {
+	if err := h.taxonomyService.DeleteTaxonomy(c.Request.Context(), c.Param("organ_type")); err != nil {
+		respondError(c, err, "taxonomy_deletion_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Taxonomy deleted successfully"})
+}
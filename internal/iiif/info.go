@@ -0,0 +1,85 @@
+package iiif
+
+import (
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+)
+
+const (
+	Context  = "http://iiif.io/api/image/3/context.json"
+	Protocol = "http://iiif.io/api/image"
+	Profile  = "level2"
+
+	// ProfileURI identifies the compliance level in Link headers.
+	ProfileURI = "http://iiif.io/api/image/3/level2.json"
+
+	// maxSizeDimension bounds the whole-image sizes advertised in info.json,
+	// which clients use for thumbnails.
+	maxSizeDimension = 1024
+)
+
+// Info is the info.json document describing an image service.
+type Info struct {
+	Context        string   `json:"@context"`
+	ID             string   `json:"id"`
+	Type           string   `json:"type"`
+	Protocol       string   `json:"protocol"`
+	Profile        string   `json:"profile"`
+	Width          int      `json:"width"`
+	Height         int      `json:"height"`
+	MaxWidth       int      `json:"maxWidth"`
+	MaxHeight      int      `json:"maxHeight"`
+	Sizes          []Size   `json:"sizes,omitempty"`
+	Tiles          []Tiles  `json:"tiles"`
+	ExtraQualities []string `json:"extraQualities"`
+	ExtraFeatures  []string `json:"extraFeatures"`
+}
+
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type Tiles struct {
+	Width        int   `json:"width"`
+	ScaleFactors []int `json:"scaleFactors"`
+}
+
+// NewInfo describes the image service with the given id (its base URI). Tiles
+// are advertised at the pyramid's tile size and at the scale factors of its
+// levels, so that tile requests map onto stored tiles.
+func NewInfo(id string, pyramid *deepzoom.Pyramid, limits Limits) *Info {
+	info := &Info{
+		Context:        Context,
+		ID:             id,
+		Type:           "ImageService3",
+		Protocol:       Protocol,
+		Profile:        Profile,
+		Width:          pyramid.Width,
+		Height:         pyramid.Height,
+		MaxWidth:       limits.MaxWidth,
+		MaxHeight:      limits.MaxHeight,
+		ExtraQualities: []string{QualityColor, QualityGray, QualityBitonal},
+		ExtraFeatures:  []string{"mirroring", "sizeUpscaling"},
+	}
+
+	// Levels from the smallest up, stopping at the first that fits in one tile.
+	var scaleFactors []int
+	for level := pyramid.MaxLevel(); level >= 0; level-- {
+		scaleFactors = append(scaleFactors, pyramid.Scale(level))
+		if columns, rows := pyramid.TileCount(level); columns == 1 && rows == 1 {
+			break
+		}
+	}
+	info.Tiles = []Tiles{{Width: pyramid.TileSize, ScaleFactors: scaleFactors}}
+
+	for level := 0; level <= pyramid.MaxLevel(); level++ {
+		width, height := pyramid.LevelSize(level)
+		if max(width, height) > min(maxSizeDimension, limits.MaxWidth, limits.MaxHeight) {
+			break
+		}
+		if max(width, height) >= 32 {
+			info.Sizes = append(info.Sizes, Size{Width: width, Height: height})
+		}
+	}
+	return info
+}
@@ -0,0 +1,247 @@
+// Package iiif implements the parts of the IIIF Image API 3.0 that do not
+// depend on HTTP: parsing image requests, describing images in info.json and
+// applying rotation and quality to rendered regions.
+package iiif
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// ErrUnsupported marks well-formed requests for features the server does not
+// implement, such as arbitrary rotation or TIFF output. The API answers them
+// with 501 Not Implemented rather than 400.
+var ErrUnsupported = errors.New("not implemented")
+
+// Qualities and formats of image requests.
+const (
+	QualityDefault = "default"
+	QualityColor   = "color"
+	QualityGray    = "gray"
+	QualityBitonal = "bitonal"
+
+	FormatJPG = "jpg"
+	FormatPNG = "png"
+)
+
+// Limits are the largest images the server returns.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Request is a parsed image request, resolved against the image's dimensions.
+type Request struct {
+	Region   image.Rectangle // In full resolution pixels
+	Width    int             // Of the returned image, before rotation
+	Height   int
+	Rotation int // Clockwise degrees: 0, 90, 180 or 270
+	Mirror   bool
+	Quality  string
+	Format   string
+}
+
+// ParseRequest parses the region, size, rotation and quality.format path
+// segments of an image request for an image of the given dimensions.
+func ParseRequest(region, size, rotation, qualityFormat string, width, height int, limits Limits) (*Request, error) {
+	req := &Request{}
+
+	var err error
+	if req.Region, err = parseRegion(region, width, height); err != nil {
+		return nil, err
+	}
+	if req.Width, req.Height, err = parseSize(size, req.Region.Dx(), req.Region.Dy(), limits); err != nil {
+		return nil, err
+	}
+	if req.Rotation, req.Mirror, err = parseRotation(rotation); err != nil {
+		return nil, err
+	}
+
+	quality, format, ok := strings.Cut(qualityFormat, ".")
+	if !ok {
+		return nil, models.NewError(models.ErrValidation, "quality and format must be given as quality.format")
+	}
+	switch quality {
+	case QualityDefault, QualityColor, QualityGray, QualityBitonal:
+		req.Quality = quality
+	default:
+		return nil, models.NewError(models.ErrValidation, "invalid quality %q", quality)
+	}
+	switch format {
+	case FormatJPG, FormatPNG:
+		req.Format = format
+	case "tif", "gif", "pdf", "jp2", "webp":
+		return nil, fmt.Errorf("%w: format %q", ErrUnsupported, format)
+	default:
+		return nil, models.NewError(models.ErrValidation, "invalid format %q", format)
+	}
+	return req, nil
+}
+
+func parseRegion(region string, width, height int) (image.Rectangle, error) {
+	bounds := image.Rect(0, 0, width, height)
+	switch region {
+	case "full":
+		return bounds, nil
+	case "square":
+		side := min(width, height)
+		x, y := (width-side)/2, (height-side)/2
+		return image.Rect(x, y, x+side, y+side), nil
+	}
+
+	var x, y, w, h float64
+	if pct, ok := strings.CutPrefix(region, "pct:"); ok {
+		values, err := parseNumbers(pct, 4, true)
+		if err != nil {
+			return image.Rectangle{}, models.NewError(models.ErrValidation, "invalid region %q", region)
+		}
+		x, y = values[0]*float64(width)/100, values[1]*float64(height)/100
+		w, h = values[2]*float64(width)/100, values[3]*float64(height)/100
+	} else {
+		values, err := parseNumbers(region, 4, false)
+		if err != nil {
+			return image.Rectangle{}, models.NewError(models.ErrValidation, "invalid region %q", region)
+		}
+		x, y, w, h = values[0], values[1], values[2], values[3]
+	}
+
+	rect := image.Rect(
+		int(math.Round(x)), int(math.Round(y)),
+		int(math.Round(x+w)), int(math.Round(y+h)),
+	)
+	if w <= 0 || h <= 0 || rect.Min.X >= width || rect.Min.Y >= height {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "region %q is empty or outside the image", region)
+	}
+	// Regions extending beyond the image are cropped to it.
+	rect = rect.Intersect(bounds)
+	if rect.Empty() {
+		return image.Rectangle{}, models.NewError(models.ErrValidation, "region %q is empty or outside the image", region)
+	}
+	return rect, nil
+}
+
+func parseSize(size string, regionWidth, regionHeight int, limits Limits) (int, int, error) {
+	invalid := models.NewError(models.ErrValidation, "invalid size %q", size)
+	spec, upscale := strings.CutPrefix(size, "^")
+	rw, rh := float64(regionWidth), float64(regionHeight)
+
+	var w, h float64
+	switch {
+	case spec == "max":
+		// The region at full size, or as large as allowed if upscaling.
+		scale := math.Min(float64(limits.MaxWidth)/rw, float64(limits.MaxHeight)/rh)
+		if !upscale {
+			scale = math.Min(scale, 1)
+		}
+		w, h = rw*scale, rh*scale
+	case strings.HasPrefix(spec, "pct:"):
+		values, err := parseNumbers(strings.TrimPrefix(spec, "pct:"), 1, true)
+		if err != nil || values[0] <= 0 || (!upscale && values[0] > 100) {
+			return 0, 0, invalid
+		}
+		w, h = rw*values[0]/100, rh*values[0]/100
+	case strings.HasPrefix(spec, "!"):
+		values, err := parseNumbers(strings.TrimPrefix(spec, "!"), 2, false)
+		if err != nil || values[0] <= 0 || values[1] <= 0 {
+			return 0, 0, invalid
+		}
+		scale := math.Min(values[0]/rw, values[1]/rh)
+		if !upscale {
+			scale = math.Min(scale, 1)
+		}
+		w, h = rw*scale, rh*scale
+	default:
+		first, second, ok := strings.Cut(spec, ",")
+		if !ok {
+			return 0, 0, invalid
+		}
+		var err error
+		switch {
+		case first != "" && second != "":
+			w, err = parseDimension(first)
+			if err == nil {
+				h, err = parseDimension(second)
+			}
+		case first != "":
+			w, err = parseDimension(first)
+			h = rh * w / rw
+		case second != "":
+			h, err = parseDimension(second)
+			w = rw * h / rh
+		default:
+			err = invalid
+		}
+		if err != nil {
+			return 0, 0, invalid
+		}
+		if !upscale && (math.Round(w) > rw || math.Round(h) > rh) {
+			return 0, 0, models.NewError(models.ErrValidation, "size %q is larger than the region; use ^ to upscale", size)
+		}
+	}
+
+	width, height := int(math.Round(w)), int(math.Round(h))
+	if width < 1 || height < 1 {
+		return 0, 0, models.NewError(models.ErrValidation, "size %q is smaller than one pixel", size)
+	}
+	if width > limits.MaxWidth || height > limits.MaxHeight {
+		return 0, 0, models.NewError(models.ErrValidation, "size %q exceeds the maximum of %dx%d pixels", size, limits.MaxWidth, limits.MaxHeight)
+	}
+	return width, height, nil
+}
+
+func parseRotation(rotation string) (int, bool, error) {
+	spec, mirror := strings.CutPrefix(rotation, "!")
+	degrees, err := strconv.ParseFloat(spec, 64)
+	if err != nil || !isDecimal(spec) || degrees > 360 {
+		return 0, false, models.NewError(models.ErrValidation, "invalid rotation %q", rotation)
+	}
+	switch degrees {
+	case 0, 360:
+		return 0, mirror, nil
+	case 90, 180, 270:
+		return int(degrees), mirror, nil
+	default:
+		return 0, false, fmt.Errorf("%w: rotation %q; only multiples of 90 are supported", ErrUnsupported, rotation)
+	}
+}
+
+// parseDimension parses a positive integer pixel count.
+func parseDimension(value string) (float64, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid dimension %q", value)
+	}
+	return float64(n), nil
+}
+
+// parseNumbers parses count comma-separated non-negative numbers, which must
+// be integers unless decimals is set.
+func parseNumbers(value string, count int, decimals bool) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != count {
+		return nil, fmt.Errorf("expected %d values", count)
+	}
+	numbers := make([]float64, count)
+	for i, part := range parts {
+		if !isDecimal(part) || (!decimals && strings.Contains(part, ".")) {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// isDecimal reports whether value is a plain decimal number such as "12" or "0.5".
+func isDecimal(value string) bool {
+	return value != "" && value != "." && strings.Trim(value, "0123456789.") == "" && strings.Count(value, ".") <= 1
+}
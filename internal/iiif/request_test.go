@@ -0,0 +1,198 @@
+package iiif
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+var testLimits = Limits{MaxWidth: 2000, MaxHeight: 2000}
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		region  string
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{region: "full", want: image.Rect(0, 0, 1000, 600)},
+		{region: "square", want: image.Rect(200, 0, 800, 600)},
+		{region: "10,20,100,50", want: image.Rect(10, 20, 110, 70)},
+		{region: "pct:10,10,50,50", want: image.Rect(100, 60, 600, 360)},
+		{region: "pct:0.5,0,10,10", want: image.Rect(5, 0, 105, 60)},
+		{region: "900,500,200,200", want: image.Rect(900, 500, 1000, 600)},
+		{region: "1000,0,10,10", wantErr: true},
+		{region: "0,600,10,10", wantErr: true},
+		{region: "0,0,0,10", wantErr: true},
+		{region: "1,2,3", wantErr: true},
+		{region: "a,b,c,d", wantErr: true},
+		{region: "-1,0,10,10", wantErr: true},
+		{region: "1.5,0,10,10", wantErr: true},
+		{region: "pct:10,10,50", wantErr: true},
+		{region: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			got, err := parseRegion(tt.region, 1000, 600)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("parseRegion(%q) error = %v, want %v", tt.region, err, models.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRegion(%q) error = %v", tt.region, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseRegion(%q) = %v, want %v", tt.region, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size      string
+		limits    Limits
+		width     int
+		height    int
+		wantError bool
+	}{
+		{size: "max", limits: testLimits, width: 1000, height: 600},
+		{size: "^max", limits: testLimits, width: 2000, height: 1200},
+		{size: "max", limits: Limits{MaxWidth: 800, MaxHeight: 800}, width: 800, height: 480},
+		{size: "pct:50", limits: testLimits, width: 500, height: 300},
+		{size: "^pct:150", limits: testLimits, width: 1500, height: 900},
+		{size: "!500,500", limits: testLimits, width: 500, height: 300},
+		{size: "^!1500,1500", limits: testLimits, width: 1500, height: 900},
+		{size: "200,", limits: testLimits, width: 200, height: 120},
+		{size: ",300", limits: testLimits, width: 500, height: 300},
+		{size: "100,100", limits: testLimits, width: 100, height: 100},
+		{size: "^2000,", limits: testLimits, width: 2000, height: 1200},
+		{size: "pct:150", limits: testLimits, wantError: true},
+		{size: "pct:0", limits: testLimits, wantError: true},
+		{size: "2000,", limits: testLimits, wantError: true},
+		{size: "^3000,", limits: testLimits, wantError: true},
+		{size: "^3000,", limits: Limits{MaxWidth: 800, MaxHeight: 800}, wantError: true},
+		{size: "!0,10", limits: testLimits, wantError: true},
+		{size: "^!3000,1500", limits: testLimits, wantError: true},
+		{size: ",", limits: testLimits, wantError: true},
+		{size: "0,", limits: testLimits, wantError: true},
+		{size: "pct:0.01", limits: testLimits, wantError: true},
+		{size: "abc", limits: testLimits, wantError: true},
+		{size: "", limits: testLimits, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			width, height, err := parseSize(tt.size, 1000, 600, tt.limits)
+			if tt.wantError {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("parseSize(%q) error = %v, want %v", tt.size, err, models.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSize(%q) error = %v", tt.size, err)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("parseSize(%q) = %dx%d, want %dx%d", tt.size, width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestParseRotation(t *testing.T) {
+	tests := []struct {
+		rotation string
+		degrees  int
+		mirror   bool
+		wantErr  error
+	}{
+		{rotation: "0"},
+		{rotation: "!0", mirror: true},
+		{rotation: "90", degrees: 90},
+		{rotation: "180", degrees: 180},
+		{rotation: "270.0", degrees: 270},
+		{rotation: "!270", degrees: 270, mirror: true},
+		{rotation: "360"},
+		{rotation: "45", wantErr: ErrUnsupported},
+		{rotation: "!22.5", wantErr: ErrUnsupported},
+		{rotation: "400", wantErr: models.ErrValidation},
+		{rotation: "-90", wantErr: models.ErrValidation},
+		{rotation: "abc", wantErr: models.ErrValidation},
+		{rotation: "!", wantErr: models.ErrValidation},
+		{rotation: "", wantErr: models.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rotation, func(t *testing.T) {
+			degrees, mirror, err := parseRotation(tt.rotation)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseRotation(%q) error = %v, want %v", tt.rotation, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRotation(%q) error = %v", tt.rotation, err)
+			}
+			if degrees != tt.degrees || mirror != tt.mirror {
+				t.Errorf("parseRotation(%q) = %d, %t, want %d, %t", tt.rotation, degrees, mirror, tt.degrees, tt.mirror)
+			}
+		})
+	}
+}
+
+func TestParseRequestQualityFormat(t *testing.T) {
+	tests := []struct {
+		qualityFormat string
+		quality       string
+		format        string
+		wantErr       error
+	}{
+		{qualityFormat: "default.jpg", quality: QualityDefault, format: FormatJPG},
+		{qualityFormat: "gray.png", quality: QualityGray, format: FormatPNG},
+		{qualityFormat: "bitonal.jpg", quality: QualityBitonal, format: FormatJPG},
+		{qualityFormat: "default.webp", wantErr: ErrUnsupported},
+		{qualityFormat: "color.tif", wantErr: ErrUnsupported},
+		{qualityFormat: "default.bmp", wantErr: models.ErrValidation},
+		{qualityFormat: "foo.jpg", wantErr: models.ErrValidation},
+		{qualityFormat: "default", wantErr: models.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.qualityFormat, func(t *testing.T) {
+			req, err := ParseRequest("full", "max", "0", tt.qualityFormat, 1000, 600, testLimits)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseRequest(%q) error = %v, want %v", tt.qualityFormat, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequest(%q) error = %v", tt.qualityFormat, err)
+			}
+			if req.Quality != tt.quality || req.Format != tt.format {
+				t.Errorf("ParseRequest(%q) = %s.%s, want %s.%s", tt.qualityFormat, req.Quality, req.Format, tt.quality, tt.format)
+			}
+		})
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	req, err := ParseRequest("pct:50,50,50,50", "pct:50", "!90", "gray.png", 1000, 600, testLimits)
+	if err != nil {
+		t.Fatalf("ParseRequest() error = %v", err)
+	}
+	want := &Request{
+		Region:   image.Rect(500, 300, 1000, 600),
+		Width:    250,
+		Height:   150,
+		Rotation: 90,
+		Mirror:   true,
+		Quality:  QualityGray,
+		Format:   FormatPNG,
+	}
+	if *req != *want {
+		t.Errorf("ParseRequest() = %+v, want %+v", req, want)
+	}
+}
@@ -0,0 +1,101 @@
+package iiif
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+)
+
+// Tile is a stored tile that answers a request on its own.
+type Tile struct {
+	Level, Column, Row int
+	Region             image.Rectangle // Requested pixels of the level, the tile's area without overlap
+	Verbatim           bool            // The stored bytes answer the request as they are
+}
+
+// StoredTile returns the stored tile whose area without overlap is exactly
+// the requested region at the resolution of the tile's level, if there is
+// one, so that the request is answered from that tile without resampling.
+// The tile answers it verbatim if it has no overlap to crop and the request
+// is unrotated and in the tiles' own format and quality.
+func StoredTile(pyramid *deepzoom.Pyramid, req *Request) (Tile, bool) {
+	level := pyramid.BestLevel(req.Region, req.Width, req.Height)
+	rect := pyramid.LevelRegion(level, req.Region)
+	if rect.Dx() != req.Width || rect.Dy() != req.Height {
+		return Tile{}, false
+	}
+	column, row := pyramid.TileAt(rect.Min.X, rect.Min.Y)
+	if pyramid.TileArea(level, column, row) != rect {
+		return Tile{}, false
+	}
+
+	tile := Tile{Level: level, Column: column, Row: row, Region: rect}
+	tile.Verbatim = pyramid.TileBounds(level, column, row) == rect &&
+		req.Rotation == 0 && !req.Mirror &&
+		(req.Quality == QualityDefault || req.Quality == QualityColor) &&
+		deepzoom.TileFormat(req.Format) == pyramid.Format
+	return tile, true
+}
+
+// Apply mirrors, rotates and converts the quality of a rendered region.
+func Apply(img *image.RGBA, req *Request) image.Image {
+	if req.Mirror {
+		img = mirror(img)
+	}
+	if req.Rotation != 0 {
+		img = rotate(img, req.Rotation)
+	}
+
+	switch req.Quality {
+	case QualityGray, QualityBitonal:
+		gray := image.NewGray(img.Bounds())
+		draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+		if req.Quality == QualityBitonal {
+			for i, v := range gray.Pix {
+				if v < 128 {
+					gray.Pix[i] = 0
+				} else {
+					gray.Pix[i] = 255
+				}
+			}
+		}
+		return gray
+	}
+	return img
+}
+
+func mirror(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.SetRGBA(b.Dx()-1-x, y, img.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// rotate turns an image clockwise by 90, 180 or 270 degrees.
+func rotate(img *image.RGBA, degrees int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if degrees != 180 {
+		w, h = h, w
+	}
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := img.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			switch degrees {
+			case 90:
+				out.SetRGBA(b.Dy()-1-y, x, c)
+			case 180:
+				out.SetRGBA(b.Dx()-1-x, b.Dy()-1-y, c)
+			case 270:
+				out.SetRGBA(y, b.Dx()-1-x, c)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package iiif
+
+import (
+	"image"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+)
+
+func TestStoredTile(t *testing.T) {
+	overlap := &deepzoom.Pyramid{Width: 1000, Height: 600, TileSize: 254, Overlap: 1, Format: "jpeg"}
+	noOverlap := &deepzoom.Pyramid{Width: 1000, Height: 600, TileSize: 254, Overlap: 0, Format: "jpeg"}
+	single := &deepzoom.Pyramid{Width: 200, Height: 100, TileSize: 254, Overlap: 1, Format: "jpeg"}
+
+	tests := []struct {
+		name     string
+		pyramid  *deepzoom.Pyramid
+		region   string
+		size     string
+		rotation string // "0" if empty
+		quality  string
+		want     Tile
+		ok       bool
+	}{
+		{name: "aligned with overlap", pyramid: overlap, region: "254,0,254,254", size: "254,254", quality: "default.jpg",
+			want: Tile{Level: 10, Column: 1, Row: 0, Region: image.Rect(254, 0, 508, 254)}, ok: true},
+		{name: "edge tile with overlap", pyramid: overlap, region: "762,508,238,92", size: "max", quality: "default.jpg",
+			want: Tile{Level: 10, Column: 3, Row: 2, Region: image.Rect(762, 508, 1000, 600)}, ok: true},
+		{name: "lower level", pyramid: overlap, region: "0,0,508,508", size: "254,254", quality: "default.jpg",
+			want: Tile{Level: 9, Column: 0, Row: 0, Region: image.Rect(0, 0, 254, 254)}, ok: true},
+		{name: "including the overlap", pyramid: overlap, region: "253,0,256,255", size: "max", quality: "default.jpg"},
+		{name: "misaligned", pyramid: overlap, region: "253,0,254,254", size: "254,254", quality: "default.jpg"},
+		{name: "scaled", pyramid: overlap, region: "254,0,254,254", size: "127,127", quality: "default.jpg"},
+		{name: "without overlap", pyramid: noOverlap, region: "254,0,254,254", size: "254,254", quality: "default.jpg",
+			want: Tile{Level: 10, Column: 1, Row: 0, Region: image.Rect(254, 0, 508, 254), Verbatim: true}, ok: true},
+		{name: "without overlap in another format", pyramid: noOverlap, region: "254,0,254,254", size: "254,254", quality: "default.png",
+			want: Tile{Level: 10, Column: 1, Row: 0, Region: image.Rect(254, 0, 508, 254)}, ok: true},
+		{name: "without overlap in gray", pyramid: noOverlap, region: "254,0,254,254", size: "254,254", quality: "gray.jpg",
+			want: Tile{Level: 10, Column: 1, Row: 0, Region: image.Rect(254, 0, 508, 254)}, ok: true},
+		{name: "without overlap rotated", pyramid: noOverlap, region: "0,0,254,254", size: "max", rotation: "90", quality: "default.jpg",
+			want: Tile{Level: 10, Region: image.Rect(0, 0, 254, 254)}, ok: true},
+		{name: "without overlap mirrored", pyramid: noOverlap, region: "0,0,254,254", size: "max", rotation: "!0", quality: "default.jpg",
+			want: Tile{Level: 10, Region: image.Rect(0, 0, 254, 254)}, ok: true},
+		{name: "single tile", pyramid: single, region: "full", size: "max", quality: "color.jpg",
+			want: Tile{Level: 8, Region: image.Rect(0, 0, 200, 100), Verbatim: true}, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rotation := tt.rotation
+			if rotation == "" {
+				rotation = "0"
+			}
+			req, err := ParseRequest(tt.region, tt.size, rotation, tt.quality, tt.pyramid.Width, tt.pyramid.Height, testLimits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := StoredTile(tt.pyramid, req)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("StoredTile() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
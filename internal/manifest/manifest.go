@@ -0,0 +1,164 @@
+// Package manifest reads the label manifests imported into the catalog: CSV
+// files with a header row, or JSON Lines with one object per row. Each row
+// names an image by file UID or file name and lists the labels to set.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// Manifest formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// maxLineBytes bounds a JSON Lines row.
+const maxLineBytes = 1 << 20
+
+// labelFields maps the manifest columns to the label they set. "subtype" is
+// accepted because the listing filter uses that spelling.
+var labelFields = map[string]func(*models.ImageUpdateRequest) **string{
+	"disease_type":   func(r *models.ImageUpdateRequest) **string { return &r.DiseaseType },
+	"classification": func(r *models.ImageUpdateRequest) **string { return &r.Classification },
+	"sub_type":       func(r *models.ImageUpdateRequest) **string { return &r.SubType },
+	"subtype":        func(r *models.ImageUpdateRequest) **string { return &r.SubType },
+	"grade":          func(r *models.ImageUpdateRequest) **string { return &r.Grade },
+}
+
+// Read parses a manifest whose rows are keyed by the matchBy column. Other
+// columns than the key and the labels are ignored. Rows that cannot be
+// parsed are returned with an error; errors that make the rest of the file
+// unreadable, a missing key column or more than models.MaxImportRows rows
+// fail the whole manifest with models.ErrValidation.
+func Read(r io.Reader, format, matchBy string) ([]*models.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, matchBy)
+	case FormatJSONL:
+		return readJSONL(r, matchBy)
+	default:
+		return nil, models.NewError(models.ErrValidation, "manifest format must be %q or %q", FormatCSV, FormatJSONL)
+	}
+}
+
+// readCSV reads a CSV manifest. Cells are trimmed, and empty cells leave
+// the label unchanged.
+func readCSV(r io.Reader, matchBy string) ([]*models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, models.NewError(models.ErrValidation, "manifest is empty")
+	}
+	if err != nil {
+		return nil, models.WrapError(models.ErrValidation, fmt.Errorf("failed to read manifest header: %w", err))
+	}
+
+	keyColumn := -1
+	labelColumns := make(map[int]func(*models.ImageUpdateRequest) **string)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == matchBy {
+			keyColumn = i
+		} else if field, ok := labelFields[name]; ok {
+			labelColumns[i] = field
+		}
+	}
+	if keyColumn < 0 {
+		return nil, models.NewError(models.ErrValidation, "manifest has no %s column", matchBy)
+	}
+
+	var rows []*models.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if len(rows) == models.MaxImportRows {
+			return nil, models.NewError(models.ErrValidation, "manifest has more than %d rows", models.MaxImportRows)
+		}
+
+		// Rows with the wrong number of fields are reported; other errors
+		// leave the reader out of step with the file.
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, models.WrapError(models.ErrValidation, fmt.Errorf("failed to read manifest: %w", err))
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &models.ImportRow{Line: line}
+		rows = append(rows, row)
+		if err != nil {
+			row.Error = fmt.Sprintf("row has %d fields, the header has %d", len(record), len(header))
+			continue
+		}
+
+		row.Key = strings.TrimSpace(record[keyColumn])
+		for i, field := range labelColumns {
+			if value := strings.TrimSpace(record[i]); value != "" {
+				*field(&row.Labels) = &value
+			}
+		}
+	}
+}
+
+// readJSONL reads a JSON Lines manifest. Labels that are missing or null
+// are left unchanged; empty strings clear them. Blank lines are skipped.
+func readJSONL(r io.Reader, matchBy string) ([]*models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+
+	var rows []*models.ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(rows) == models.MaxImportRows {
+			return nil, models.NewError(models.ErrValidation, "manifest has more than %d rows", models.MaxImportRows)
+		}
+		row := &models.ImportRow{Line: line}
+		rows = append(rows, row)
+		if err := parseObject(data, matchBy, row); err != nil {
+			row.Error = err.Error()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, models.WrapError(models.ErrValidation, fmt.Errorf("failed to read manifest: %w", err))
+	}
+	if len(rows) == 0 {
+		return nil, models.NewError(models.ErrValidation, "manifest is empty")
+	}
+	return rows, nil
+}
+
+func parseObject(data []byte, matchBy string, row *models.ImportRow) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("invalid JSON object: %v", err)
+	}
+
+	if object[matchBy] == nil || json.Unmarshal(object[matchBy], &row.Key) != nil {
+		return fmt.Errorf("%s must be a string", matchBy)
+	}
+	for name, value := range object {
+		field, ok := labelFields[name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		var label string
+		if err := json.Unmarshal(value, &label); err != nil {
+			return fmt.Errorf("%s must be a string or null", name)
+		}
+		*field(&row.Labels) = &label
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// labels returns the labels set on a row as a map, for comparison.
+func labels(r *models.ImageUpdateRequest) map[string]string {
+	set := make(map[string]string)
+	for name, value := range map[string]*string{
+		"disease_type":   r.DiseaseType,
+		"classification": r.Classification,
+		"sub_type":       r.SubType,
+		"grade":          r.Grade,
+	} {
+		if value != nil {
+			set[name] = *value
+		}
+	}
+	return set
+}
+
+type wantRow struct {
+	line   int
+	key    string
+	labels map[string]string
+	err    string // Substring of the row error, if any
+}
+
+func checkRows(t *testing.T, got []*models.ImportRow, want []wantRow) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		row := got[i]
+		if row.Line != w.line || row.Key != w.key {
+			t.Errorf("row %d = line %d key %q, want line %d key %q", i, row.Line, row.Key, w.line, w.key)
+		}
+		if w.err != "" {
+			if !strings.Contains(row.Error, w.err) {
+				t.Errorf("row %d error = %q, want it to contain %q", i, row.Error, w.err)
+			}
+			continue
+		}
+		if row.Error != "" {
+			t.Errorf("row %d error = %q", i, row.Error)
+		}
+		gotLabels := labels(&row.Labels)
+		if len(gotLabels) != len(w.labels) {
+			t.Errorf("row %d labels = %v, want %v", i, gotLabels, w.labels)
+			continue
+		}
+		for name, value := range w.labels {
+			if gotLabels[name] != value {
+				t.Errorf("row %d labels = %v, want %v", i, gotLabels, w.labels)
+				break
+			}
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		matchBy string
+		want    []wantRow
+		wantErr bool
+	}{
+		{
+			name:    "labels",
+			input:   "file_uid,grade,notes,disease_type\nu1, 2 ,x,carcinoma\nu2,,y,\n",
+			matchBy: models.ImportMatchFileUID,
+			want: []wantRow{
+				{line: 2, key: "u1", labels: map[string]string{"grade": "2", "disease_type": "carcinoma"}},
+				{line: 3, key: "u2", labels: map[string]string{}},
+			},
+		},
+		{
+			name:    "subtype alias, BOM and header case",
+			input:   "\ufeffFile_Name,SubType\na.svs,ductal\n",
+			matchBy: models.ImportMatchFileName,
+			want:    []wantRow{{line: 2, key: "a.svs", labels: map[string]string{"sub_type": "ductal"}}},
+		},
+		{
+			name:    "wrong field count",
+			input:   "file_uid,grade\nu1,1\nu2,2,extra\nu3,3\n",
+			matchBy: models.ImportMatchFileUID,
+			want: []wantRow{
+				{line: 2, key: "u1", labels: map[string]string{"grade": "1"}},
+				{line: 3, err: "row has 3 fields, the header has 2"},
+				{line: 4, key: "u3", labels: map[string]string{"grade": "3"}},
+			},
+		},
+		{name: "empty", input: "", matchBy: models.ImportMatchFileUID, wantErr: true},
+		{name: "missing key column", input: "file_name,grade\na.svs,1\n", matchBy: models.ImportMatchFileUID, wantErr: true},
+		{name: "bare quote", input: "file_uid,grade\nu1,\"1\n", matchBy: models.ImportMatchFileUID, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Read(strings.NewReader(tt.input), FormatCSV, tt.matchBy)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("Read() error = %v, want %v", err, models.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			checkRows(t, rows, tt.want)
+		})
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []wantRow
+		wantErr bool
+	}{
+		{
+			name:  "labels",
+			input: `{"file_uid":"u1","grade":"2","classification":"","notes":1}` + "\n\n" + `{"file_uid":"u2","subtype":"ductal","grade":null}` + "\n",
+			want: []wantRow{
+				{line: 1, key: "u1", labels: map[string]string{"grade": "2", "classification": ""}},
+				{line: 3, key: "u2", labels: map[string]string{"sub_type": "ductal"}},
+			},
+		},
+		{
+			name:  "row errors",
+			input: "{\"file_uid\":\"u1\"\n" + `{"file_uid":7}` + "\n" + `{"grade":"1"}` + "\n" + `{"file_uid":"u4","grade":3}` + "\n" + `["u5"]`,
+			want: []wantRow{
+				{line: 1, err: "invalid JSON object"},
+				{line: 2, err: "file_uid must be a string"},
+				{line: 3, err: "file_uid must be a string"},
+				{line: 4, key: "u4", err: "grade must be a string or null"},
+				{line: 5, err: "invalid JSON object"},
+			},
+		},
+		{name: "empty", input: "", wantErr: true},
+		{name: "blank lines only", input: "\n  \n", wantErr: true},
+		{name: "line too long", input: `{"file_uid":"` + strings.Repeat("x", maxLineBytes) + `"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Read(strings.NewReader(tt.input), FormatJSONL, models.ImportMatchFileUID)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Fatalf("Read() error = %v, want %v", err, models.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			checkRows(t, rows, tt.want)
+		})
+	}
+}
+
+func TestReadUnknownFormat(t *testing.T) {
+	_, err := Read(strings.NewReader("file_uid\nu1\n"), "xlsx", models.ImportMatchFileUID)
+	if !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Read() error = %v, want %v", err, models.ErrValidation)
+	}
+}
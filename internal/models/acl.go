@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Dataset permissions, from weakest to strongest. Each one implies the ones before it.
+const (
+	PermissionRead     = "read"
+	PermissionAnnotate = "annotate"
+	PermissionAdmin    = "admin"
+)
+
+var permissionRank = map[string]int{
+	PermissionRead:     1,
+	PermissionAnnotate: 2,
+	PermissionAdmin:    3,
+}
+
+// ValidPermission reports whether p is one of the dataset permissions.
+func ValidPermission(p string) bool {
+	return permissionRank[p] > 0
+}
+
+// PermissionImplies reports whether holding granted also allows required.
+func PermissionImplies(granted, required string) bool {
+	return permissionRank[granted] > 0 && permissionRank[granted] >= permissionRank[required]
+}
+
+// Grant subjects are written as "user:<id>" or "group:<name>".
+const (
+	subjectUserPrefix  = "user:"
+	subjectGroupPrefix = "group:"
+)
+
+func UserSubject(userID string) string {
+	return subjectUserPrefix + userID
+}
+
+func GroupSubject(group string) string {
+	return subjectGroupPrefix + group
+}
+
+// ValidSubject reports whether s is a well-formed user or group subject.
+func ValidSubject(s string) bool {
+	for _, prefix := range []string{subjectUserPrefix, subjectGroupPrefix} {
+		if rest, ok := strings.CutPrefix(s, prefix); ok {
+			return strings.TrimSpace(rest) != ""
+		}
+	}
+	return false
+}
+
+// DatasetGrant gives a user or group a permission on every image of a dataset.
+type DatasetGrant struct {
+	DatasetName string    `json:"dataset_name" firestore:"dataset_name"`
+	Subject     string    `json:"subject" firestore:"subject"`
+	Permission  string    `json:"permission" firestore:"permission"`
+	GrantedBy   string    `json:"granted_by" firestore:"granted_by"`
+	GrantedAt   time.Time `json:"granted_at" firestore:"granted_at"`
+}
+
+type DatasetGrantRequest struct {
+	Subject    string `json:"subject"`
+	Permission string `json:"permission"`
+}
+
+// Validate checks the subject and permission of a grant request.
+func (r *DatasetGrantRequest) Validate() error {
+	if !ValidSubject(r.Subject) {
+		return NewError(ErrValidation, "subject must be \"user:<id>\" or \"group:<name>\"")
+	}
+	if !ValidPermission(r.Permission) {
+		return NewError(ErrValidation, "permission must be one of %q, %q or %q", PermissionRead, PermissionAnnotate, PermissionAdmin)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Annotation geometry types.
+const (
+	GeometryPoint     = "point"
+	GeometryRectangle = "rectangle"
+	GeometryPolygon   = "polygon"
+)
+
+const (
+	// MaxAnnotationLabelLength is the longest label accepted, in bytes.
+	MaxAnnotationLabelLength = 200
+	// MaxPolygonPoints bounds the vertices of a polygon so an annotation
+	// stays well below the Firestore document size limit.
+	MaxPolygonPoints = 10000
+)
+
+// Point is a position on a slide in full resolution pixel coordinates, with
+// the origin at the top left corner.
+type Point struct {
+	X float64 `json:"x" firestore:"x"`
+	Y float64 `json:"y" firestore:"y"`
+}
+
+// Geometry is the shape of an annotation. A point has one vertex, a
+// rectangle two opposite corners, and a polygon at least three vertices of
+// an implicitly closed ring. Vertices are stored as a flat list because
+// Firestore cannot store nested arrays.
+type Geometry struct {
+	Type   string  `json:"type" firestore:"type"`
+	Points []Point `json:"points" firestore:"points"`
+}
+
+// Validate checks the vertex count of the geometry and, if the slide's
+// dimensions are known, that every vertex lies on the slide.
+func (g *Geometry) Validate(width, height int) error {
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Points) != 1 {
+			return NewError(ErrValidation, "a point must have exactly one vertex")
+		}
+	case GeometryRectangle:
+		if len(g.Points) != 2 {
+			return NewError(ErrValidation, "a rectangle must have exactly two opposite corners")
+		}
+		if g.Points[0].X == g.Points[1].X || g.Points[0].Y == g.Points[1].Y {
+			return NewError(ErrValidation, "a rectangle must have a positive width and height")
+		}
+	case GeometryPolygon:
+		if len(g.Points) < 3 || len(g.Points) > MaxPolygonPoints {
+			return NewError(ErrValidation, "a polygon must have between 3 and %d vertices", MaxPolygonPoints)
+		}
+	default:
+		return NewError(ErrValidation, "geometry type must be one of %q, %q or %q", GeometryPoint, GeometryRectangle, GeometryPolygon)
+	}
+
+	for _, p := range g.Points {
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsInf(p.X, 0) || math.IsInf(p.Y, 0) {
+			return NewError(ErrValidation, "vertex coordinates must be finite numbers")
+		}
+		if p.X < 0 || p.Y < 0 || (width > 0 && p.X > float64(width)) || (height > 0 && p.Y > float64(height)) {
+			return NewError(ErrValidation, "vertex (%g, %g) lies outside the slide", p.X, p.Y)
+		}
+	}
+	return nil
+}
+
+// Ring returns the closed ring of a rectangle or polygon, as used by GeoJSON:
+// the first vertex is repeated at the end. Rectangles are expanded to their
+// four corners.
+func (g *Geometry) Ring() []Point {
+	var ring []Point
+	switch g.Type {
+	case GeometryRectangle:
+		a, b := g.Points[0], g.Points[1]
+		ring = []Point{a, {X: b.X, Y: a.Y}, b, {X: a.X, Y: b.Y}}
+	case GeometryPolygon:
+		ring = append(ring, g.Points...)
+	default:
+		return nil
+	}
+	if ring[0] != ring[len(ring)-1] {
+		ring = append(ring, ring[0])
+	}
+	return ring
+}
+
+// Annotation is a labelled region or point drawn on a slide.
+type Annotation struct {
+	ID        string    `json:"id" firestore:"-"`
+	ImageID   string    `json:"image_id" firestore:"image_id"`
+	Label     string    `json:"label" firestore:"label"`
+	Geometry  Geometry  `json:"geometry" firestore:"geometry"`
+	CreatedBy string    `json:"created_by" firestore:"created_by"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedBy string    `json:"updated_by,omitempty" firestore:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+type AnnotationCreateRequest struct {
+	Label    string   `json:"label"`
+	Geometry Geometry `json:"geometry"`
+}
+
+// Validate checks the label and geometry of a new annotation on a slide of
+// the given dimensions.
+func (r *AnnotationCreateRequest) Validate(width, height int) error {
+	if err := validateAnnotationLabel(r.Label); err != nil {
+		return err
+	}
+	return r.Geometry.Validate(width, height)
+}
+
+type AnnotationUpdateRequest struct {
+	Label    *string   `json:"label,omitempty"`
+	Geometry *Geometry `json:"geometry,omitempty"`
+}
+
+// Validate checks the fields being changed.
+func (r *AnnotationUpdateRequest) Validate(width, height int) error {
+	if r.Label != nil {
+		if err := validateAnnotationLabel(*r.Label); err != nil {
+			return err
+		}
+	}
+	if r.Geometry != nil {
+		return r.Geometry.Validate(width, height)
+	}
+	return nil
+}
+
+func validateAnnotationLabel(label string) error {
+	if strings.TrimSpace(label) == "" {
+		return NewError(ErrValidation, "label is required")
+	}
+	if len(label) > MaxAnnotationLabelLength {
+		return NewError(ErrValidation, "label must be at most %d bytes", MaxAnnotationLabelLength)
+	}
+	return nil
+}
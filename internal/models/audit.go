@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions on images.
+const (
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditActorSystem is the actor of changes made by the service itself, such
+// as purging expired images from the trash.
+const AuditActorSystem = "system"
+
+// AuditEntry records one change to an image. An update produces one entry
+// per changed field; other actions produce a single entry without a field.
+// Entries are never modified once written.
+type AuditEntry struct {
+	ID          string    `json:"id" firestore:"-"`
+	ImageID     string    `json:"image_id" firestore:"image_id"`
+	DatasetName string    `json:"dataset_name" firestore:"dataset_name"`
+	Action      string    `json:"action" firestore:"action"`
+	Field       string    `json:"field,omitempty" firestore:"field,omitempty"`
+	OldValue    *string   `json:"old_value,omitempty" firestore:"old_value,omitempty"`
+	NewValue    *string   `json:"new_value,omitempty" firestore:"new_value,omitempty"`
+	Actor       string    `json:"actor" firestore:"actor"`
+	Timestamp   time.Time `json:"timestamp" firestore:"timestamp"`
+	RequestID   string    `json:"request_id,omitempty" firestore:"request_id,omitempty"`
+}
+
+// AuditFilter selects audit entries. Entries are returned newest first.
+type AuditFilter struct {
+	ImageID string
+	Actor   string
+	From    time.Time // Inclusive; zero means unbounded
+	To      time.Time // Exclusive; zero means unbounded
+
+	Limit     int
+	PageToken string
+}
+
+// Normalize fills in the default page size and validates the filter.
+func (f *AuditFilter) Normalize() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit < 0 || f.Limit > MaxPageSize {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxPageSize)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return NewError(ErrValidation, "from must be before to")
+	}
+	if f.PageToken != "" {
+		if _, err := DecodeAuditCursor(f.PageToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type AuditList struct {
+	Entries       []*AuditEntry `json:"entries"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+}
+
+// AuditCursor is the decoded form of an audit page token. It records the
+// timestamp and ID of the last entry on the previous page.
+type AuditCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the opaque page token for the cursor.
+func (c *AuditCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeAuditCursor parses an audit page token.
+func DecodeAuditCursor(token string) (*AuditCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, NewError(ErrValidation, "invalid page token")
+	}
+	var cursor AuditCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.Timestamp.IsZero() {
+		return nil, NewError(ErrValidation, "invalid page token")
+	}
+	return &cursor, nil
+}
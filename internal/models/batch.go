@@ -0,0 +1,121 @@
+package models
+
+const (
+	// MaxBatchImages bounds the images one batch update may select.
+	MaxBatchImages = 5000
+	// MaxBatchWrites is the number of images a batch reads and writes at a
+	// time, bounding the size of Firestore's GetAll calls.
+	MaxBatchWrites = 500
+)
+
+// Outcomes of a batch update for one image.
+const (
+	BatchStatusUpdated     = "updated"
+	BatchStatusWouldUpdate = "would_update" // Dry runs only
+	BatchStatusUnchanged   = "unchanged"
+	BatchStatusNotFound    = "not_found"
+	BatchStatusForbidden   = "forbidden"
+	BatchStatusConflict    = "conflict" // Changed concurrently; retry
+	BatchStatusFailed      = "failed"
+	BatchStatusInvalid     = "invalid" // The labels are invalid for the image
+)
+
+// ImageBatchUpdateRequest applies one update to several images, selected
+// either by ID or by filter.
+type ImageBatchUpdateRequest struct {
+	IDs    []string           `json:"ids,omitempty"`
+	Filter *ImageFilter       `json:"filter,omitempty"`
+	Update ImageUpdateRequest `json:"update"`
+	DryRun bool               `json:"dry_run,omitempty"`
+}
+
+// Validate checks that exactly one selector is given and that the update
+// only changes the label fields, which are the ones batches can write.
+func (r *ImageBatchUpdateRequest) Validate() error {
+	if (len(r.IDs) > 0) == (r.Filter != nil) {
+		return NewError(ErrValidation, "exactly one of ids or filter is required")
+	}
+	if len(r.IDs) > MaxBatchImages {
+		return NewError(ErrValidation, "at most %d ids can be updated at once", MaxBatchImages)
+	}
+	if r.Filter != nil && !r.Filter.HasCriteria() {
+		return NewError(ErrValidation, "filter must set at least one field")
+	}
+	if r.Update.DatasetName != nil || r.Update.OrganType != nil {
+		return NewError(ErrValidation, "dataset_name and organ_type cannot be changed in a batch")
+	}
+	if !r.Update.HasLabels() {
+		return NewError(ErrValidation, "update must set at least one of disease_type, classification, sub_type or grade")
+	}
+	return r.Update.ValidateLabels()
+}
+
+// FieldChange is the change of one field of an image.
+type FieldChange struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old_value,omitempty"`
+	NewValue *string `json:"new_value,omitempty"`
+}
+
+// LabelChanges returns the label fields the update would change on the image.
+func (r *ImageUpdateRequest) LabelChanges(image *Image) []FieldChange {
+	var changes []FieldChange
+	changes = appendChange(changes, "disease_type", image.DiseaseType, r.DiseaseType)
+	changes = appendChange(changes, "classification", image.Classification, r.Classification)
+	changes = appendChange(changes, "sub_type", image.SubType, r.SubType)
+	changes = appendChange(changes, "grade", image.Grade, r.Grade)
+	return changes
+}
+
+// Changes returns every field the update would change on the image: its
+// dataset name, organ type and labels.
+func (r *ImageUpdateRequest) Changes(image *Image) []FieldChange {
+	// Copy the current values, which the caller overwrites when applying the update.
+	datasetName, organType := image.DatasetName, image.OrganType
+	var changes []FieldChange
+	changes = appendChange(changes, "dataset_name", &datasetName, r.DatasetName)
+	changes = appendChange(changes, "organ_type", &organType, r.OrganType)
+	return append(changes, r.LabelChanges(image)...)
+}
+
+// appendChange adds a change of the field to changes if after is set and
+// differs from before.
+func appendChange(changes []FieldChange, field string, before, after *string) []FieldChange {
+	if after != nil && (before == nil || *before != *after) {
+		changes = append(changes, FieldChange{Field: field, OldValue: before, NewValue: after})
+	}
+	return changes
+}
+
+// ApplyLabels sets the label fields of the update on the image.
+func (r *ImageUpdateRequest) ApplyLabels(image *Image) {
+	if r.DiseaseType != nil {
+		image.DiseaseType = r.DiseaseType
+	}
+	if r.Classification != nil {
+		image.Classification = r.Classification
+	}
+	if r.SubType != nil {
+		image.SubType = r.SubType
+	}
+	if r.Grade != nil {
+		image.Grade = r.Grade
+	}
+}
+
+// ImageBatchResult is the outcome of a batch update for one image.
+type ImageBatchResult struct {
+	ID      string        `json:"id"`
+	Status  string        `json:"status"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Version int64         `json:"version,omitempty"` // The new version of updated images
+	Error   string        `json:"error,omitempty"`
+}
+
+type ImageBatchUpdateResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Matched int                 `json:"matched"`
+	Updated int                 `json:"updated"`
+	Failed  int                 `json:"failed"`
+	Results []*ImageBatchResult `json:"results"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds shared by the adapters, services and handlers. Adapters
+// translate backend errors into these kinds and handlers map them to HTTP
+// statuses; test them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrPrecondition = errors.New("precondition failed")
+	ErrValidation   = errors.New("validation failed")
+	ErrPermission   = errors.New("permission denied")
+	ErrUnavailable  = errors.New("service unavailable")
+)
+
+// Error is an error classified as one of the kinds above.
+type Error struct {
+	kind    error
+	message string
+	err     error
+}
+
+// NewError returns an error of the given kind with a formatted message.
+func NewError(kind error, format string, args ...interface{}) error {
+	return &Error{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+// WrapError classifies err as the given kind while keeping it in the chain.
+func WrapError(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{kind: kind, err: err}
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.err != nil {
+		return []error{e.kind, e.err}
+	}
+	return []error{e.kind}
+}
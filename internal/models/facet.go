@@ -0,0 +1,123 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// FacetFields are the fields images can be counted by, in response order.
+var FacetFields = []string{"dataset_name", "organ_type", "disease_type", "classification", "sub_type", "grade"}
+
+// FacetBucket is the number of images with one value of a field.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facet counts the images matching a filter by the values of one field.
+type Facet struct {
+	Field   string        `json:"field"`
+	Buckets []FacetBucket `json:"buckets"`           // Most frequent first
+	Other   int64         `json:"other"`             // Images without a value, or with one the buckets do not cover
+	Sampled int64         `json:"sampled,omitempty"` // If set, the buckets and Other count only this many images
+}
+
+// FacetList is the response of a facet query.
+type FacetList struct {
+	Total  int64    `json:"total"`
+	Facets []*Facet `json:"facets"`
+}
+
+// ParseFacetFields parses a comma-separated list of facet fields. "subtype"
+// is accepted for sub_type because the listing filter uses that spelling.
+func ParseFacetFields(list string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field == "subtype" {
+			field = "sub_type"
+		}
+		switch {
+		case field == "":
+			continue
+		case !slices.Contains(FacetFields, field):
+			return nil, NewError(ErrValidation, "unknown facet field %q; use %s", field, strings.Join(FacetFields, ", "))
+		case slices.Contains(fields, field):
+			return nil, NewError(ErrValidation, "facet field %q is selected twice", field)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, NewError(ErrValidation, "fields must list at least one of %s", strings.Join(FacetFields, ", "))
+	}
+	return fields, nil
+}
+
+// FacetValue returns the value of a facet field of the image, or "" if it
+// is not set.
+func (i *Image) FacetValue(field string) string {
+	var value *string
+	switch field {
+	case "dataset_name":
+		return i.DatasetName
+	case "organ_type":
+		return i.OrganType
+	case "disease_type":
+		value = i.DiseaseType
+	case "classification":
+		value = i.Classification
+	case "sub_type":
+		value = i.SubType
+	case "grade":
+		value = i.Grade
+	}
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// FacetValue returns the value the filter requires for a facet field, or
+// "" if it does not restrict the field.
+func (f *ImageFilter) FacetValue(field string) string {
+	var value *string
+	switch field {
+	case "dataset_name":
+		value = f.DatasetName
+	case "organ_type":
+		value = f.OrganType
+	case "disease_type":
+		value = f.DiseaseType
+	case "classification":
+		value = f.Classification
+	case "sub_type":
+		value = f.SubType
+	case "grade":
+		value = f.Grade
+	}
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// WithFacetValue returns a copy of the filter that also requires a facet
+// field to have the value. A dataset name replaces the filter's dataset
+// restriction, so it must be one of the allowed datasets.
+func (f *ImageFilter) WithFacetValue(field, value string) *ImageFilter {
+	filter := *f
+	switch field {
+	case "dataset_name":
+		filter.DatasetName, filter.DatasetNames = &value, nil
+	case "organ_type":
+		filter.OrganType = &value
+	case "disease_type":
+		filter.DiseaseType = &value
+	case "classification":
+		filter.Classification = &value
+	case "sub_type":
+		filter.SubType = &value
+	case "grade":
+		filter.Grade = &value
+	}
+	return &filter
+}
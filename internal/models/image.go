@@ -1,7 +1,11 @@
 package models
 
 import (
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Image struct {
@@ -25,9 +29,21 @@ type Image struct {
 	Size   int64  `json:"size" firestore:"size"`
 	Format string `json:"format"`
 
+	// Deep Zoom tiling; unset values mean the pipeline defaults
+	TileSize int  `json:"tile_size,omitempty" firestore:"tile_size,omitempty"`
+	Overlap  *int `json:"overlap,omitempty" firestore:"overlap,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+
+	// Version counts the updates of the record; images created before it
+	// was introduced start at 0.
+	Version int64 `json:"version" firestore:"version"`
+
+	// Soft deletion
+	DeletedAt *time.Time `json:"deleted_at,omitempty" firestore:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty" firestore:"deleted_by,omitempty"`
 }
 
 type ImageFilter struct {
@@ -37,6 +53,252 @@ type ImageFilter struct {
 	Classification *string `json:"classification,omitempty" firestore:"classification,omitempty"`
 	SubType        *string `json:"sub_type,omitempty" firestore:"sub_type,omitempty"`
 	Grade          *string `json:"grade,omitempty" firestore:"grade,omitempty"`
+
+	// Pagination and sorting
+	Limit     int    `json:"limit,omitempty" firestore:"-"`
+	PageToken string `json:"page_token,omitempty" firestore:"-"`
+	SortBy    string `json:"sort_by,omitempty" firestore:"-"`
+	Order     string `json:"order,omitempty" firestore:"-"`
+
+	// Deleted lists images in the trash instead of active ones.
+	Deleted bool `json:"-" firestore:"-"`
+	// DatasetNames restricts the listing to these datasets; set by access control.
+	DatasetNames []string `json:"-" firestore:"-"`
+}
+
+// HasCriteria reports whether the filter sets any of its equality fields.
+func (f *ImageFilter) HasCriteria() bool {
+	for _, value := range []*string{f.DatasetName, f.OrganType, f.DiseaseType, f.Classification, f.SubType, f.Grade} {
+		if value != nil && *value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type ImageUpdateRequest struct {
+	DatasetName    *string `json:"dataset_name,omitempty"`
+	OrganType      *string `json:"organ_type,omitempty"`
+	DiseaseType    *string `json:"disease_type,omitempty"`
+	Classification *string `json:"classification,omitempty"`
+	SubType        *string `json:"sub_type,omitempty"`
+	Grade          *string `json:"grade,omitempty"`
+}
+
+// MaxLabelLength bounds the length of a diagnostic label.
+const MaxLabelLength = 100
+
+// ValidateLabels checks the label values the update sets: they must be at
+// most MaxLabelLength characters without control characters or surrounding
+// whitespace. Empty values clear a label.
+func (r *ImageUpdateRequest) ValidateLabels() error {
+	for _, label := range []struct {
+		name  string
+		value *string
+	}{
+		{"disease_type", r.DiseaseType},
+		{"classification", r.Classification},
+		{"sub_type", r.SubType},
+		{"grade", r.Grade},
+	} {
+		if label.value == nil {
+			continue
+		}
+		value := *label.value
+		switch {
+		case !utf8.ValidString(value):
+			return NewError(ErrValidation, "%s is not valid UTF-8", label.name)
+		case utf8.RuneCountInString(value) > MaxLabelLength:
+			return NewError(ErrValidation, "%s must be at most %d characters", label.name, MaxLabelLength)
+		case strings.ContainsFunc(value, unicode.IsControl):
+			return NewError(ErrValidation, "%s contains control characters", label.name)
+		case strings.TrimSpace(value) != value:
+			return NewError(ErrValidation, "%s has leading or trailing whitespace", label.name)
+		}
+	}
+	return nil
 }
 
-type ImageUpdateRequest ImageFilter
+// Validate checks the fields the update sets. The dataset name and the
+// organ type cannot be cleared; labels are checked as in ValidateLabels.
+func (r *ImageUpdateRequest) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"dataset_name", r.DatasetName},
+		{"organ_type", r.OrganType},
+	} {
+		if field.value != nil && strings.TrimSpace(*field.value) == "" {
+			return NewError(ErrValidation, "%s must not be empty", field.name)
+		}
+	}
+	return r.ValidateLabels()
+}
+
+// HasLabels reports whether the update sets any label field.
+func (r *ImageUpdateRequest) HasLabels() bool {
+	return r.DiseaseType != nil || r.Classification != nil || r.SubType != nil || r.Grade != nil
+}
+
+type ImageCreateRequest struct {
+	FileName       string  `json:"file_name"`
+	FileUID        string  `json:"file_uid"`
+	DatasetName    string  `json:"dataset_name"`
+	OrganType      string  `json:"organ_type"`
+	DiseaseType    *string `json:"disease_type,omitempty"`
+	Classification *string `json:"classification,omitempty"`
+	SubType        *string `json:"sub_type,omitempty"`
+	Grade          *string `json:"grade,omitempty"`
+
+	DZIGCSPath       string `json:"dzi_gcs_path"`
+	TilesGCSPath     string `json:"tiles_gcs_path"`
+	ThumbnailGCSPath string `json:"thumbnail_gcs_path"`
+
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+	Format string `json:"format"`
+
+	TileSize int  `json:"tile_size,omitempty"`
+	Overlap  *int `json:"overlap,omitempty"`
+}
+
+// Validate checks that all fields required to register an image are present.
+func (r *ImageCreateRequest) Validate() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"file_name", r.FileName},
+		{"file_uid", r.FileUID},
+		{"dataset_name", r.DatasetName},
+		{"organ_type", r.OrganType},
+		{"dzi_gcs_path", r.DZIGCSPath},
+		{"tiles_gcs_path", r.TilesGCSPath},
+		{"thumbnail_gcs_path", r.ThumbnailGCSPath},
+	}
+
+	var missing []string
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return NewError(ErrValidation, "missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	if r.Width < 0 || r.Height < 0 || r.Size < 0 {
+		return NewError(ErrValidation, "width, height and size must not be negative")
+	}
+	if r.TileSize < 0 {
+		return NewError(ErrValidation, "tile_size must not be negative")
+	}
+	if r.Overlap != nil && (*r.Overlap < 0 || (r.TileSize > 0 && *r.Overlap >= r.TileSize)) {
+		return NewError(ErrValidation, "overlap must be between 0 and tile_size - 1")
+	}
+	return nil
+}
+
+// ImageList is a single page of images returned by a filtered listing.
+type ImageList struct {
+	Images        []*Image `json:"images"`
+	NextPageToken string   `json:"next_page_token,omitempty"`
+	TotalCount    int64    `json:"total_count"`
+}
+
+// ObjectName converts a stored GCS path, which may be a full gs:// URI, into
+// an object name relative to the bucket.
+func ObjectName(gcsPath string) string {
+	name := strings.TrimSpace(gcsPath)
+	if rest, ok := strings.CutPrefix(name, "gs://"); ok {
+		_, name, _ = strings.Cut(rest, "/")
+	}
+	return strings.TrimPrefix(name, "/")
+}
+
+// Kinds of objects that belong to an image.
+const (
+	ObjectKindTile      = "tile"
+	ObjectKindDZI       = "dzi"
+	ObjectKindThumbnail = "thumbnail"
+)
+
+// ObjectKind returns which of the image's assets the object is: its DZI
+// descriptor, its thumbnail or a tile under the tiles prefix. It returns ""
+// if the object does not belong to the image.
+func (i *Image) ObjectKind(name string) string {
+	switch {
+	case name == "":
+		return ""
+	case name == ObjectName(i.DZIGCSPath):
+		return ObjectKindDZI
+	case name == ObjectName(i.ThumbnailGCSPath):
+		return ObjectKindThumbnail
+	}
+	prefix := strings.TrimSuffix(ObjectName(i.TilesGCSPath), "/")
+	if prefix != "" && strings.HasPrefix(name, prefix+"/") {
+		return ObjectKindTile
+	}
+	return ""
+}
+
+// OwnsObject reports whether the object is one of the image's assets.
+func (i *Image) OwnsObject(name string) bool {
+	return i.ObjectKind(name) != ""
+}
+
+// OverlapsPrefix reports whether deleting every object under the prefix,
+// which ends in "/", would touch the image's assets: its DZI or thumbnail
+// lies under the prefix, or its tiles prefix lies under or contains it.
+func (i *Image) OverlapsPrefix(prefix string) bool {
+	for _, name := range []string{ObjectName(i.DZIGCSPath), ObjectName(i.ThumbnailGCSPath)} {
+		if name != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	tiles := strings.TrimSuffix(ObjectName(i.TilesGCSPath), "/")
+	return tiles != "" && (strings.HasPrefix(tiles+"/", prefix) || strings.HasPrefix(prefix, tiles+"/"))
+}
+
+// ETag returns the strong entity tag of the image record's current version.
+func (i *Image) ETag() string {
+	return `"` + strconv.FormatInt(i.Version, 10) + `"`
+}
+
+// ParseImageETag returns the version named by an image entity tag. Weak
+// tags are not accepted because updates need a strong comparison.
+func ParseImageETag(etag string) (int64, bool) {
+	value, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	if value, ok = strings.CutSuffix(value, `"`); !ok {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// DerivedPrefix returns the prefix of the objects the service derives from
+// the image, such as thumbnails at requested sizes.
+func (i *Image) DerivedPrefix() string {
+	return "derived/" + i.ID + "/"
+}
+
+// ObjectPrefixes returns the parent directories of an object name, with and
+// without a trailing slash, which are the candidate tiles prefixes of the
+// image that owns it.
+func ObjectPrefixes(name string) []string {
+	var prefixes []string
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' && i > 0 {
+			prefixes = append(prefixes, name[:i], name[:i+1])
+		}
+	}
+	return prefixes
+}
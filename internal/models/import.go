@@ -0,0 +1,65 @@
+package models
+
+// MaxImportRows bounds the rows of one imported manifest.
+const MaxImportRows = 20000
+
+// Fields by which manifest rows are matched to images.
+const (
+	ImportMatchFileUID  = "file_uid"
+	ImportMatchFileName = "file_name"
+)
+
+// BatchStatusAmbiguous is the outcome of an import row whose file name
+// matches several images, in addition to the batch update outcomes.
+const BatchStatusAmbiguous = "ambiguous"
+
+// ImportRow is one row of a manifest: the file that identifies an image and
+// the labels to set on it. Rows that could not be parsed carry an error.
+type ImportRow struct {
+	Line   int                // Line of the row in the manifest, starting at 1
+	Key    string             // File UID or file name, depending on the match field
+	Labels ImageUpdateRequest // Only the label fields are used
+	Error  string
+}
+
+// ImportRequest sets the labels of the images listed in a manifest.
+type ImportRequest struct {
+	MatchBy string
+	DryRun  bool
+	Rows    []*ImportRow
+}
+
+// Validate checks the match field and the number of rows.
+func (r *ImportRequest) Validate() error {
+	if r.MatchBy != ImportMatchFileUID && r.MatchBy != ImportMatchFileName {
+		return NewError(ErrValidation, "match_by must be %q or %q", ImportMatchFileUID, ImportMatchFileName)
+	}
+	if len(r.Rows) == 0 {
+		return NewError(ErrValidation, "manifest has no rows")
+	}
+	if len(r.Rows) > MaxImportRows {
+		return NewError(ErrValidation, "manifest has more than %d rows", MaxImportRows)
+	}
+	return nil
+}
+
+// ImportRowResult is the outcome of one manifest row.
+type ImportRowResult struct {
+	Line    int           `json:"line"`
+	Key     string        `json:"key"`
+	ImageID string        `json:"image_id,omitempty"`
+	Status  string        `json:"status"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Version int64         `json:"version,omitempty"` // The new version of updated images
+	Error   string        `json:"error,omitempty"`
+}
+
+// ImportReport lists the outcome of every manifest row in manifest order.
+type ImportReport struct {
+	DryRun    bool               `json:"dry_run"`
+	Rows      int                `json:"rows"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Results   []*ImportRowResult `json:"results"`
+}
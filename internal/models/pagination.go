@@ -0,0 +1,127 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+
+	SortByCreatedAt = "created_at"
+	SortByFileName  = "file_name"
+	SortBySize      = "size"
+	SortByWidth     = "width"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// PageSize returns the number of images a page of the filter holds: its
+// Limit, or DefaultPageSize if the limit is not positive.
+func (f *ImageFilter) PageSize() int {
+	if f.Limit <= 0 {
+		return DefaultPageSize
+	}
+	return f.Limit
+}
+
+// NormalizePagination fills in the default page size and sort order and
+// validates the pagination fields of the filter.
+func (f *ImageFilter) NormalizePagination() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit < 0 || f.Limit > MaxPageSize {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxPageSize)
+	}
+
+	if f.SortBy == "" {
+		f.SortBy = SortByCreatedAt
+	}
+	switch f.SortBy {
+	case SortByCreatedAt, SortByFileName, SortBySize, SortByWidth:
+	default:
+		return NewError(ErrValidation, "unsupported sort_by %q", f.SortBy)
+	}
+
+	if f.Order == "" {
+		f.Order = OrderDesc
+		if f.SortBy == SortByFileName {
+			f.Order = OrderAsc
+		}
+	}
+	if f.Order != OrderAsc && f.Order != OrderDesc {
+		return NewError(ErrValidation, "order must be %q or %q", OrderAsc, OrderDesc)
+	}
+	return nil
+}
+
+// PageCursor is the decoded form of an opaque page token. It records the sort
+// key and ID of the last image on the previous page.
+type PageCursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     string `json:"id"`
+}
+
+// NewPageCursor builds the cursor that continues a listing after the given image.
+func NewPageCursor(image *Image, sortBy, order string) *PageCursor {
+	cursor := &PageCursor{SortBy: sortBy, Order: order, ID: image.ID}
+	switch sortBy {
+	case SortByCreatedAt:
+		cursor.Value = image.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case SortByFileName:
+		cursor.Value = image.FileName
+	case SortBySize:
+		cursor.Value = strconv.FormatInt(image.Size, 10)
+	case SortByWidth:
+		cursor.Value = strconv.Itoa(image.Width)
+	}
+	return cursor
+}
+
+// Encode returns the opaque page token for the cursor.
+func (c *PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor parses a page token and checks that it was issued for the
+// same sort field and order as the current request.
+func DecodePageCursor(token, sortBy, order string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, NewError(ErrValidation, "invalid page token")
+	}
+
+	var cursor PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, NewError(ErrValidation, "invalid page token")
+	}
+	if cursor.SortBy != sortBy || cursor.Order != order {
+		return nil, NewError(ErrValidation, "page token does not match sort_by and order")
+	}
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, NewError(ErrValidation, "invalid page token")
+	}
+	return &cursor, nil
+}
+
+// SortValue returns the typed sort key stored in the cursor.
+func (c *PageCursor) SortValue() (interface{}, error) {
+	switch c.SortBy {
+	case SortByCreatedAt:
+		return time.Parse(time.RFC3339Nano, c.Value)
+	case SortByFileName:
+		return c.Value, nil
+	case SortBySize, SortByWidth:
+		return strconv.ParseInt(c.Value, 10, 64)
+	default:
+		return nil, NewError(ErrValidation, "unsupported sort_by %q", c.SortBy)
+	}
+}
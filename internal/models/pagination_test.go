@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	image := &Image{
+		ID:        "img-1",
+		FileName:  "slide, 01.svs",
+		Size:      123456789,
+		Width:     40000,
+		CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 890, time.FixedZone("CET", 3600)),
+	}
+	tests := []struct {
+		sortBy string
+		order  string
+		want   interface{}
+	}{
+		{sortBy: SortByCreatedAt, order: OrderDesc, want: image.CreatedAt.UTC()},
+		{sortBy: SortByFileName, order: OrderAsc, want: "slide, 01.svs"},
+		{sortBy: SortBySize, order: OrderDesc, want: int64(123456789)},
+		{sortBy: SortByWidth, order: OrderAsc, want: int64(40000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			token := NewPageCursor(image, tt.sortBy, tt.order).Encode()
+			cursor, err := DecodePageCursor(token, tt.sortBy, tt.order)
+			if err != nil {
+				t.Fatalf("DecodePageCursor() error = %v", err)
+			}
+			if cursor.ID != image.ID {
+				t.Errorf("cursor ID = %q, want %q", cursor.ID, image.ID)
+			}
+			value, err := cursor.SortValue()
+			if err != nil {
+				t.Fatalf("SortValue() error = %v", err)
+			}
+			if got, ok := value.(time.Time); ok {
+				if !got.Equal(tt.want.(time.Time)) {
+					t.Errorf("SortValue() = %v, want %v", got, tt.want)
+				}
+			} else if value != tt.want {
+				t.Errorf("SortValue() = %v (%T), want %v (%T)", value, value, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	image := &Image{ID: "img-1", FileName: "a.svs", CreatedAt: time.Now()}
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := []struct {
+		name   string
+		token  string
+		sortBy string
+		order  string
+	}{
+		{name: "other sort field", token: NewPageCursor(image, SortByFileName, OrderAsc).Encode(), sortBy: SortByCreatedAt, order: OrderAsc},
+		{name: "other order", token: NewPageCursor(image, SortByFileName, OrderAsc).Encode(), sortBy: SortByFileName, order: OrderDesc},
+		{name: "not base64", token: "!!!", sortBy: SortByFileName, order: OrderAsc},
+		{name: "not JSON", token: encode("nope"), sortBy: SortByFileName, order: OrderAsc},
+		{name: "missing ID", token: encode(`{"s":"file_name","o":"asc","v":"a.svs"}`), sortBy: SortByFileName, order: OrderAsc},
+		{name: "bad time", token: encode(`{"s":"created_at","o":"desc","v":"yesterday","id":"img-1"}`), sortBy: SortByCreatedAt, order: OrderDesc},
+		{name: "bad number", token: encode(`{"s":"size","o":"desc","v":"big","id":"img-1"}`), sortBy: SortBySize, order: OrderDesc},
+		{name: "unknown sort field", token: encode(`{"s":"grade","o":"asc","v":"1","id":"img-1"}`), sortBy: "grade", order: OrderAsc},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodePageCursor(tt.token, tt.sortBy, tt.order)
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("DecodePageCursor() error = %v, want %v", err, ErrValidation)
+			}
+		})
+	}
+}
+
+func TestAuditCursorRoundTrip(t *testing.T) {
+	want := &AuditCursor{Timestamp: time.Date(2026, 3, 4, 5, 6, 7, 890, time.UTC), ID: "entry-1"}
+	got, err := DecodeAuditCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("DecodeAuditCursor() error = %v", err)
+	}
+	if !got.Timestamp.Equal(want.Timestamp) || got.ID != want.ID {
+		t.Errorf("DecodeAuditCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeAuditCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "!!!"},
+		{name: "not JSON", token: base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{name: "missing ID", token: (&AuditCursor{Timestamp: time.Now()}).Encode()},
+		{name: "missing timestamp", token: (&AuditCursor{ID: "entry-1"}).Encode()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeAuditCursor(tt.token)
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("DecodeAuditCursor() error = %v, want %v", err, ErrValidation)
+			}
+		})
+	}
+}
+
+func TestNormalizePagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  ImageFilter
+		want    ImageFilter
+		wantErr bool
+	}{
+		{name: "defaults", want: ImageFilter{Limit: DefaultPageSize, SortBy: SortByCreatedAt, Order: OrderDesc}},
+		{name: "file name ascending", filter: ImageFilter{SortBy: SortByFileName}, want: ImageFilter{Limit: DefaultPageSize, SortBy: SortByFileName, Order: OrderAsc}},
+		{name: "negative limit", filter: ImageFilter{Limit: -1}, wantErr: true},
+		{name: "limit too large", filter: ImageFilter{Limit: MaxPageSize + 1}, wantErr: true},
+		{name: "unknown sort field", filter: ImageFilter{SortBy: "grade"}, wantErr: true},
+		{name: "unknown order", filter: ImageFilter{Order: "up"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.NormalizePagination()
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("NormalizePagination() error = %v, want %v", err, ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizePagination() error = %v", err)
+			}
+			if tt.filter.Limit != tt.want.Limit || tt.filter.SortBy != tt.want.SortBy || tt.filter.Order != tt.want.Order {
+				t.Errorf("NormalizePagination() = %d %s %s, want %d %s %s",
+					tt.filter.Limit, tt.filter.SortBy, tt.filter.Order, tt.want.Limit, tt.want.SortBy, tt.want.Order)
+			}
+		})
+	}
+}
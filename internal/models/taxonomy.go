@@ -0,0 +1,308 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Code systems that terms are commonly mapped to. Other systems are accepted.
+const (
+	CodeSystemSNOMEDCT = "SNOMED-CT"
+	CodeSystemICDO3    = "ICD-O-3"
+)
+
+// maxListedTerms bounds the allowed values quoted in validation errors.
+const maxListedTerms = 20
+
+// Code identifies a term in an external terminology such as SNOMED CT or ICD-O.
+type Code struct {
+	System  string `json:"system" firestore:"system"`
+	Code    string `json:"code" firestore:"code"`
+	Display string `json:"display,omitempty" firestore:"display,omitempty"`
+}
+
+// Term is an allowed label value. Labels matching the value or one of the
+// aliases, ignoring case and repeated spaces, are stored as the value.
+type Term struct {
+	Value   string   `json:"value" firestore:"value"`
+	Aliases []string `json:"aliases,omitempty" firestore:"aliases,omitempty"`
+	Codes   []Code   `json:"codes,omitempty" firestore:"codes,omitempty"`
+}
+
+// GradeScheme is a named set of grades, e.g. the Nottingham grades of
+// invasive breast carcinoma.
+type GradeScheme struct {
+	Name   string `json:"name" firestore:"name"`
+	Grades []Term `json:"grades" firestore:"grades"`
+}
+
+// SubTypeTerm is an allowed subtype of a disease.
+type SubTypeTerm struct {
+	Term
+	GradeScheme string `json:"grade_scheme,omitempty" firestore:"grade_scheme,omitempty"` // Overrides the disease's scheme
+}
+
+// DiseaseTerm is an allowed disease of an organ with the classifications
+// and subtypes allowed for it.
+type DiseaseTerm struct {
+	Term
+	Classifications []Term        `json:"classifications,omitempty" firestore:"classifications,omitempty"`
+	SubTypes        []SubTypeTerm `json:"sub_types,omitempty" firestore:"sub_types,omitempty"`
+	GradeScheme     string        `json:"grade_scheme,omitempty" firestore:"grade_scheme,omitempty"` // Grades of images without a subtype scheme
+}
+
+// Taxonomy defines the diagnostic labels allowed on images of one organ
+// type as a hierarchy: organ, disease, subtype and grade scheme.
+type Taxonomy struct {
+	OrganType    string        `json:"organ_type" firestore:"-"`
+	Diseases     []DiseaseTerm `json:"diseases" firestore:"diseases"`
+	GradeSchemes []GradeScheme `json:"grade_schemes,omitempty" firestore:"grade_schemes,omitempty"`
+	UpdatedBy    string        `json:"updated_by" firestore:"updated_by"`
+	UpdatedAt    time.Time     `json:"updated_at" firestore:"updated_at"`
+}
+
+// TaxonomyRequest replaces the taxonomy of an organ type.
+type TaxonomyRequest struct {
+	Diseases     []DiseaseTerm `json:"diseases"`
+	GradeSchemes []GradeScheme `json:"grade_schemes,omitempty"`
+}
+
+// ValidateOrganType checks that an organ type can name a taxonomy.
+func ValidateOrganType(organType string) error {
+	switch {
+	case organType == "" || organType == "." || organType == "..":
+		return NewError(ErrValidation, "organ type %q is invalid", organType)
+	case len(organType) > MaxLabelLength || strings.ContainsFunc(organType, func(r rune) bool { return r == '/' || unicode.IsControl(r) }):
+		return NewError(ErrValidation, "organ type must be at most %d characters without slashes or control characters", MaxLabelLength)
+	}
+	return nil
+}
+
+// Validate checks that every term is a valid label, that no two terms on
+// the same level match the same label, that codes are complete and that
+// grade schemes referenced by diseases and subtypes exist.
+func (t *Taxonomy) Validate() error {
+	if err := ValidateOrganType(t.OrganType); err != nil {
+		return err
+	}
+	if len(t.Diseases) == 0 {
+		return NewError(ErrValidation, "taxonomy must define at least one disease")
+	}
+
+	schemes := make(map[string]bool, len(t.GradeSchemes))
+	for _, scheme := range t.GradeSchemes {
+		if scheme.Name == "" || schemes[scheme.Name] {
+			return NewError(ErrValidation, "grade scheme names must be unique and not empty")
+		}
+		schemes[scheme.Name] = true
+		if err := validateTerms("grade scheme "+scheme.Name, scheme.Grades); err != nil {
+			return err
+		}
+	}
+	checkScheme := func(owner, name string) error {
+		if name != "" && !schemes[name] {
+			return NewError(ErrValidation, "%s refers to undefined grade scheme %q", owner, name)
+		}
+		return nil
+	}
+
+	diseases := make([]Term, len(t.Diseases))
+	for i, disease := range t.Diseases {
+		diseases[i] = disease.Term
+		owner := fmt.Sprintf("disease %q", disease.Value)
+		if err := checkScheme(owner, disease.GradeScheme); err != nil {
+			return err
+		}
+		if err := validateTerms(owner+" classifications", disease.Classifications); err != nil {
+			return err
+		}
+		subTypes := make([]Term, len(disease.SubTypes))
+		for j, subType := range disease.SubTypes {
+			subTypes[j] = subType.Term
+			if err := checkScheme(fmt.Sprintf("%s subtype %q", owner, subType.Value), subType.GradeScheme); err != nil {
+				return err
+			}
+		}
+		if err := validateTerms(owner+" subtypes", subTypes); err != nil {
+			return err
+		}
+	}
+	return validateTerms("diseases", diseases)
+}
+
+// validateTerms checks the terms of one level of the hierarchy.
+func validateTerms(level string, terms []Term) error {
+	seen := make(map[string]string)
+	for _, term := range terms {
+		if term.Value == "" {
+			return NewError(ErrValidation, "%s: values must not be empty", level)
+		}
+		for _, label := range append([]string{term.Value}, term.Aliases...) {
+			value := label
+			if err := (&ImageUpdateRequest{DiseaseType: &value}).ValidateLabels(); err != nil {
+				return NewError(ErrValidation, "%s: %q is not a valid label", level, label)
+			}
+			key := normalizeTerm(label)
+			if other, ok := seen[key]; ok && other != term.Value {
+				return NewError(ErrValidation, "%s: %q matches both %q and %q", level, label, other, term.Value)
+			} else if ok {
+				return NewError(ErrValidation, "%s: %q is listed twice for %q", level, label, term.Value)
+			}
+			seen[key] = term.Value
+		}
+		for _, code := range term.Codes {
+			if strings.TrimSpace(code.System) == "" || strings.TrimSpace(code.Code) == "" {
+				return NewError(ErrValidation, "%s: codes of %q need a system and a code", level, term.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeTerm folds case and runs of whitespace for matching labels to terms.
+func normalizeTerm(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// findTerm returns the term matching the label, or nil.
+func findTerm(terms []Term, label string) *Term {
+	key := normalizeTerm(label)
+	for i := range terms {
+		if normalizeTerm(terms[i].Value) == key {
+			return &terms[i]
+		}
+		for _, alias := range terms[i].Aliases {
+			if normalizeTerm(alias) == key {
+				return &terms[i]
+			}
+		}
+	}
+	return nil
+}
+
+// unknownTerm returns the validation error for a label that matches none
+// of the allowed terms.
+func unknownTerm(field, label, context string, terms []Term) error {
+	if len(terms) == 0 {
+		return NewError(ErrValidation, "no %s values are defined for %s", field, context)
+	}
+	values := make([]string, 0, min(len(terms), maxListedTerms))
+	for _, term := range terms[:min(len(terms), maxListedTerms)] {
+		values = append(values, fmt.Sprintf("%q", term.Value))
+	}
+	if len(terms) > maxListedTerms {
+		values = append(values, "...")
+	}
+	return NewError(ErrValidation, "%s %q is not defined for %s; allowed: %s", field, label, context, strings.Join(values, ", "))
+}
+
+// ResolveLabels checks the labels the update sets on the image against the
+// taxonomy and returns a copy of the update with each label replaced by the
+// value of the term it matches. Labels below a changed label in the
+// hierarchy are checked too, even if the update leaves them alone, so that
+// e.g. changing the disease cannot leave a subtype of another disease
+// behind. Empty labels are not checked.
+func (t *Taxonomy) ResolveLabels(image *Image, update *ImageUpdateRequest) (*ImageUpdateRequest, error) {
+	resolved := *update
+	effective := func(set, stored *string) string {
+		if set != nil {
+			return *set
+		}
+		if stored != nil {
+			return *stored
+		}
+		return ""
+	}
+	diseaseLabel := effective(update.DiseaseType, image.DiseaseType)
+	classificationLabel := effective(update.Classification, image.Classification)
+	subTypeLabel := effective(update.SubType, image.SubType)
+	gradeLabel := effective(update.Grade, image.Grade)
+
+	checkClassification := update.Classification != nil || update.DiseaseType != nil
+	checkSubType := update.SubType != nil || update.DiseaseType != nil
+	checkGrade := update.Grade != nil || checkSubType
+	if !(update.DiseaseType != nil || checkClassification || checkSubType || checkGrade) {
+		return &resolved, nil
+	}
+	needsDisease := (checkClassification && classificationLabel != "") || (checkSubType && subTypeLabel != "") || (checkGrade && gradeLabel != "")
+	if diseaseLabel == "" {
+		if needsDisease {
+			return nil, NewError(ErrValidation, "disease_type must be set before classification, sub_type or grade")
+		}
+		return &resolved, nil
+	}
+
+	organ := fmt.Sprintf("organ type %q", t.OrganType)
+	diseaseTerms := make([]Term, len(t.Diseases))
+	var disease *DiseaseTerm
+	for i := range t.Diseases {
+		diseaseTerms[i] = t.Diseases[i].Term
+	}
+	if term := findTerm(diseaseTerms, diseaseLabel); term != nil {
+		for i := range t.Diseases {
+			if t.Diseases[i].Value == term.Value {
+				disease = &t.Diseases[i]
+			}
+		}
+	}
+	if disease == nil {
+		if update.DiseaseType == nil {
+			return nil, NewError(ErrValidation, "disease_type %q of the image is not defined for %s; set a valid disease_type first", diseaseLabel, organ)
+		}
+		return nil, unknownTerm("disease_type", diseaseLabel, organ, diseaseTerms)
+	}
+	if update.DiseaseType != nil {
+		resolved.DiseaseType = &disease.Value
+	}
+	context := fmt.Sprintf("disease %q", disease.Value)
+
+	if checkClassification && classificationLabel != "" {
+		term := findTerm(disease.Classifications, classificationLabel)
+		if term == nil {
+			return nil, unknownTerm("classification", classificationLabel, context, disease.Classifications)
+		}
+		if update.Classification != nil {
+			resolved.Classification = &term.Value
+		}
+	}
+
+	schemeName := disease.GradeScheme
+	if subTypeLabel != "" && (checkSubType || checkGrade) {
+		subTypeTerms := make([]Term, len(disease.SubTypes))
+		for i := range disease.SubTypes {
+			subTypeTerms[i] = disease.SubTypes[i].Term
+		}
+		term := findTerm(subTypeTerms, subTypeLabel)
+		if term == nil {
+			return nil, unknownTerm("sub_type", subTypeLabel, context, subTypeTerms)
+		}
+		if update.SubType != nil {
+			resolved.SubType = &term.Value
+		}
+		for _, subType := range disease.SubTypes {
+			if subType.Value == term.Value && subType.GradeScheme != "" {
+				schemeName = subType.GradeScheme
+				context = fmt.Sprintf("subtype %q of %s", subType.Value, context)
+			}
+		}
+	}
+
+	if checkGrade && gradeLabel != "" {
+		var grades []Term
+		for _, scheme := range t.GradeSchemes {
+			if scheme.Name == schemeName {
+				grades = scheme.Grades
+			}
+		}
+		term := findTerm(grades, gradeLabel)
+		if term == nil {
+			return nil, unknownTerm("grade", gradeLabel, context, grades)
+		}
+		if update.Grade != nil {
+			resolved.Grade = &term.Value
+		}
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var breastTaxonomy = &Taxonomy{
+	OrganType: "breast",
+	Diseases: []DiseaseTerm{
+		{
+			Term:            Term{Value: "Invasive carcinoma", Aliases: []string{"IC"}},
+			Classifications: []Term{{Value: "malignant"}},
+			SubTypes: []SubTypeTerm{
+				{Term: Term{Value: "ductal", Aliases: []string{"NST"}}},
+				{Term: Term{Value: "lobular"}, GradeScheme: "lobular"},
+			},
+			GradeScheme: "nottingham",
+		},
+		{Term: Term{Value: "fibroadenoma"}},
+	},
+	GradeSchemes: []GradeScheme{
+		{Name: "nottingham", Grades: []Term{{Value: "1", Aliases: []string{"G1"}}, {Value: "2", Aliases: []string{"G2"}}, {Value: "3", Aliases: []string{"G3"}}}},
+		{Name: "lobular", Grades: []Term{{Value: "classic"}, {Value: "pleomorphic"}}},
+	},
+}
+
+func label(value string) *string { return &value }
+
+// labels formats the label fields of an update for comparison.
+func labels(u *ImageUpdateRequest) string {
+	value := func(s *string) string {
+		if s == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%q", *s)
+	}
+	return fmt.Sprintf("dataset=%s organ=%s disease=%s classification=%s sub_type=%s grade=%s",
+		value(u.DatasetName), value(u.OrganType), value(u.DiseaseType), value(u.Classification), value(u.SubType), value(u.Grade))
+}
+
+func TestTaxonomyResolveLabels(t *testing.T) {
+	carcinoma := &Image{DiseaseType: label("Invasive carcinoma")}
+	tests := []struct {
+		name    string
+		image   *Image
+		update  ImageUpdateRequest
+		want    ImageUpdateRequest
+		wantErr bool
+	}{
+		{
+			name:   "no labels",
+			image:  &Image{DiseaseType: label("unknown")},
+			update: ImageUpdateRequest{DatasetName: label("breast-2")},
+			want:   ImageUpdateRequest{DatasetName: label("breast-2")},
+		},
+		{
+			name:   "disease alias",
+			image:  &Image{},
+			update: ImageUpdateRequest{DiseaseType: label("ic")},
+			want:   ImageUpdateRequest{DiseaseType: label("Invasive carcinoma")},
+		},
+		{
+			name:   "disease case and spaces",
+			image:  &Image{},
+			update: ImageUpdateRequest{DiseaseType: label(" invasive   CARCINOMA ")},
+			want:   ImageUpdateRequest{DiseaseType: label("Invasive carcinoma")},
+		},
+		{
+			name:    "unknown disease",
+			image:   &Image{},
+			update:  ImageUpdateRequest{DiseaseType: label("adenocarcinoma")},
+			wantErr: true,
+		},
+		{
+			name:   "subtype alias under the stored disease",
+			image:  carcinoma,
+			update: ImageUpdateRequest{SubType: label("nst")},
+			want:   ImageUpdateRequest{SubType: label("ductal")},
+		},
+		{
+			name:   "classification",
+			image:  carcinoma,
+			update: ImageUpdateRequest{Classification: label("Malignant")},
+			want:   ImageUpdateRequest{Classification: label("malignant")},
+		},
+		{
+			name:   "grade of the disease scheme",
+			image:  carcinoma,
+			update: ImageUpdateRequest{Grade: label("g2")},
+			want:   ImageUpdateRequest{Grade: label("2")},
+		},
+		{
+			name:   "grade of the subtype scheme",
+			image:  &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("lobular")},
+			update: ImageUpdateRequest{Grade: label("Pleomorphic")},
+			want:   ImageUpdateRequest{Grade: label("pleomorphic")},
+		},
+		{
+			name:    "grade of another scheme",
+			image:   &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("lobular")},
+			update:  ImageUpdateRequest{Grade: label("2")},
+			wantErr: true,
+		},
+		{
+			name:    "subtype change leaves a grade of another scheme",
+			image:   &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("ductal"), Grade: label("3")},
+			update:  ImageUpdateRequest{SubType: label("lobular")},
+			wantErr: true,
+		},
+		{
+			name:    "disease change leaves a subtype of another disease",
+			image:   &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("ductal")},
+			update:  ImageUpdateRequest{DiseaseType: label("fibroadenoma")},
+			wantErr: true,
+		},
+		{
+			name:   "disease change with its subtype",
+			image:  &Image{DiseaseType: label("fibroadenoma")},
+			update: ImageUpdateRequest{DiseaseType: label("IC"), SubType: label("Lobular"), Grade: label("classic")},
+			want:   ImageUpdateRequest{DiseaseType: label("Invasive carcinoma"), SubType: label("lobular"), Grade: label("classic")},
+		},
+		{
+			name:    "subtype without a disease",
+			image:   &Image{},
+			update:  ImageUpdateRequest{SubType: label("ductal")},
+			wantErr: true,
+		},
+		{
+			name:    "disease cleared below a subtype",
+			image:   &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("ductal")},
+			update:  ImageUpdateRequest{DiseaseType: label("")},
+			wantErr: true,
+		},
+		{
+			name:   "labels cleared",
+			image:  &Image{DiseaseType: label("Invasive carcinoma"), SubType: label("ductal"), Grade: label("1")},
+			update: ImageUpdateRequest{DiseaseType: label(""), SubType: label(""), Grade: label("")},
+			want:   ImageUpdateRequest{DiseaseType: label(""), SubType: label(""), Grade: label("")},
+		},
+		{
+			name:    "subtype under an undefined stored disease",
+			image:   &Image{DiseaseType: label("adenocarcinoma")},
+			update:  ImageUpdateRequest{SubType: label("ductal")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breastTaxonomy.ResolveLabels(tt.image, &tt.update)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("ResolveLabels() error = %v, want %v", err, ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveLabels() error = %v", err)
+			}
+			if labels(got) != labels(&tt.want) {
+				t.Errorf("ResolveLabels() = %s, want %s", labels(got), labels(&tt.want))
+			}
+		})
+	}
+}
+
+func TestTaxonomyResolveLabelsKeepsUpdate(t *testing.T) {
+	update := &ImageUpdateRequest{DiseaseType: label("ic")}
+	if _, err := breastTaxonomy.ResolveLabels(&Image{}, update); err != nil {
+		t.Fatal(err)
+	}
+	if *update.DiseaseType != "ic" {
+		t.Errorf("update disease_type = %q, want it unchanged", *update.DiseaseType)
+	}
+}
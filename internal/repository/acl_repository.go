@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+type ACLRepository interface {
+	// ListBySubjects returns every grant held by any of the subjects.
+	ListBySubjects(ctx context.Context, subjects []string) ([]*models.DatasetGrant, error)
+	ListByDataset(ctx context.Context, datasetName string) ([]*models.DatasetGrant, error)
+	// Put creates the grant or replaces the permission of an existing one for the same dataset and subject.
+	Put(ctx context.Context, grant *models.DatasetGrant) error
+	Delete(ctx context.Context, datasetName, subject string) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+type AnnotationRepository interface {
+	// Create stores a new annotation and assigns its ID.
+	Create(ctx context.Context, annotation *models.Annotation) error
+	// Read returns an annotation of the image; annotations of other images are not found.
+	Read(ctx context.Context, imageID, annotationID string) (*models.Annotation, error)
+	// Update replaces the label, geometry and update stamp of an annotation.
+	Update(ctx context.Context, annotation *models.Annotation) error
+	Delete(ctx context.Context, imageID, annotationID string) error
+	// ListByImage returns every annotation of an image, oldest first.
+	ListByImage(ctx context.Context, imageID string) ([]*models.Annotation, error)
+	// DeleteByImage removes every annotation of an image.
+	DeleteByImage(ctx context.Context, imageID string) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// AuditRepository queries the audit log. Entries are only ever appended by
+// the ImageRepository, together with the change they describe.
+type AuditRepository interface {
+	// Query returns a page of the entries matching the filter, newest first.
+	Query(ctx context.Context, filter *models.AuditFilter) (*models.AuditList, error)
+}
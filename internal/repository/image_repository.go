@@ -2,13 +2,60 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/histopathai/image-catalog-service/internal/models"
 )
 
+// ImageRepository stores image records. The methods that change a record
+// take the audit entries describing the change and write them to the audit
+// log atomically with it, so a change is never applied without its entries.
 type ImageRepository interface {
+	// Create stores a new image, assigns its ID and fails with codes.AlreadyExists
+	// if another image has the same FileUID.
+	Create(ctx context.Context, image *models.Image) error
 	Read(ctx context.Context, imageID string) (*models.Image, error)
-	Update(ctx context.Context, image *models.Image) error
-	Delete(ctx context.Context, imageID string) error
-	Filter(ctx context.Context, filter *models.ImageFilter) ([]*models.Image, error)
+	// Update writes the dataset name, organ type and label fields of the
+	// image if the stored record is still at the given version, atomically
+	// incrementing the version and setting image.Version to the result. It
+	// fails with models.ErrPrecondition if the record has been changed since.
+	Update(ctx context.Context, image *models.Image, version int64, entries []*models.AuditEntry) error
+	// ReadMany returns the active images among the IDs; missing IDs are skipped.
+	ReadMany(ctx context.Context, imageIDs []string) ([]*models.Image, error)
+	// ListByField returns the active images whose field equals one of the
+	// values. The field is models.ImportMatchFileUID or models.ImportMatchFileName.
+	ListByField(ctx context.Context, field string, values []string) ([]*models.Image, error)
+	// UpdateBatch writes the label fields of up to models.MaxBatchWrites
+	// images. Each image is written atomically with its entries, and only if
+	// it is still at its Version; an image that cannot be written does not
+	// prevent the others from being written. Images that are
+	// missing, have changed or fail to be written are skipped and reported in
+	// the returned map with models.ErrNotFound, models.ErrPrecondition or the
+	// write error; the others have their Version incremented.
+	UpdateBatch(ctx context.Context, images []*models.Image, entries []*models.AuditEntry) (map[string]error, error)
+	// Delete permanently removes an image, whether it is active or in the trash.
+	Delete(ctx context.Context, imageID string, entries []*models.AuditEntry) error
+	Filter(ctx context.Context, filter *models.ImageFilter) (*models.ImageList, error)
+	// Count returns the number of images matching the filter without
+	// reading them. Limit, PageToken and the sort order are ignored.
+	Count(ctx context.Context, filter *models.ImageFilter) (int64, error)
+	// Iterate calls fn with every image matching the filter in its sort
+	// order, reading them from the backend as it goes rather than all at
+	// once. Limit and PageToken are ignored. It stops at the first error
+	// returned by fn and returns it.
+	Iterate(ctx context.Context, filter *models.ImageFilter, fn func(*models.Image) error) error
+	// FindByObjectPath returns the active image that owns the object (see models.Image.OwnsObject).
+	FindByObjectPath(ctx context.Context, objectName string) (*models.Image, error)
+	// ListOverlapping returns the images, active or in the trash, whose
+	// assets would be touched by deleting every object under the prefix (see
+	// models.Image.OverlapsPrefix).
+	ListOverlapping(ctx context.Context, prefix string) ([]*models.Image, error)
+
+	// SoftDelete moves an active image to the trash, hiding it from Read and Filter.
+	SoftDelete(ctx context.Context, imageID, deletedBy string, deletedAt time.Time, entries []*models.AuditEntry) error
+	// Restore moves an image from the trash back to the active images. The
+	// entries' DatasetName is set to the dataset of the restored record.
+	Restore(ctx context.Context, imageID string, entries []*models.AuditEntry) error
+	// ListDeletedBefore returns up to limit trashed images deleted before cutoff, oldest first.
+	ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]*models.Image, error)
 }
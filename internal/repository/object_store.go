@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ObjectAttrs describes a stored object.
+type ObjectAttrs struct {
+	Name         string
+	ContentType  string
+	Size         int64
+	ETag         string // Strong entity tag, including the quotes
+	LastModified time.Time
+	// Version identifies this revision of the object. Passing it to
+	// OpenRange guarantees that the bytes read belong to the same revision.
+	Version string
+}
+
+// ObjectStore is the blob storage used for the files that belong to an
+// image: tiles, DZI descriptors and thumbnails. Object names are
+// slash-separated paths relative to the bucket (or root directory) of the
+// store. Methods return models.ErrNotFound for objects that do not exist.
+type ObjectStore interface {
+	// Stat returns the attributes of an object.
+	Stat(ctx context.Context, name string) (*ObjectAttrs, error)
+	// Open reads a whole object.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// OpenRange reads length bytes of an object starting at offset; a
+	// negative length reads to the end. If version is not empty and the
+	// object has been replaced since, it fails with models.ErrNotFound or
+	// models.ErrConflict.
+	OpenRange(ctx context.Context, name, version string, offset, length int64) (io.ReadCloser, error)
+	// List returns the names of all objects whose name starts with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes a single object. Deleting an object that does not exist is not an error.
+	Delete(ctx context.Context, name string) error
+	// Write creates or replaces an object with the contents of r.
+	Write(ctx context.Context, name, contentType string, r io.Reader) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// TaxonomyRepository stores one label taxonomy per organ type.
+type TaxonomyRepository interface {
+	Read(ctx context.Context, organType string) (*models.Taxonomy, error)
+	// List returns every taxonomy ordered by organ type.
+	List(ctx context.Context) ([]*models.Taxonomy, error)
+	// Put creates the taxonomy of its organ type or replaces the existing one.
+	Put(ctx context.Context, taxonomy *models.Taxonomy) error
+	Delete(ctx context.Context, organType string) error
+}
@@ -0,0 +1,50 @@
+// Package requestid carries the identifier of the HTTP request being served
+// through its context, so that records written on its behalf can be traced
+// back to it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header through which request IDs are accepted and returned.
+const Header = "X-Request-ID"
+
+// maxLength bounds request IDs supplied by clients.
+const maxLength = 128
+
+// New returns a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether a client supplied ID may be used: it must be short
+// and consist of printable ASCII without spaces.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+type idKey struct{}
+
+// WithID returns a copy of ctx carrying the request ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+)
+
+// Authenticate resolves the principal of every request and stores it in the
+// request context. Requests without valid credentials are rejected with 401.
+func Authenticate(authenticator auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			slog.Debug("Rejected unauthenticated request", "path", c.FullPath(), "error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated", "message": "Valid credentials are required."})
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests whose principal has none of the given roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		if !principal.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "You do not have permission to perform this action."})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// customMethodParam is the path parameter of routes serving custom methods.
+const customMethodParam = "custom_method"
+
+// CustomMethod restricts a route registered as "/:custom_method" to one
+// custom method, such as "images:batchUpdate", whose name puts a colon inside
+// the last path segment. gin reads any colon in a route pattern as the start
+// of a parameter, so the pattern cannot spell the name out; the whole segment
+// is matched instead and compared with the name here. Other segments get the
+// same 404 as an unknown route.
+func CustomMethod(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param(customMethodParam) != name {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	}
+}
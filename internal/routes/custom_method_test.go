@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCustomMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.PATCH("/:"+customMethodParam, CustomMethod("images:batchUpdate"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	api.PATCH("/images/:image_id", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/api/v1/images:batchUpdate", status: http.StatusNoContent},
+		{path: "/api/v1/images%3AbatchUpdate", status: http.StatusNoContent},
+		{path: "/api/v1/images:batchDelete", status: http.StatusNotFound},
+		{path: "/api/v1/imagesbatchUpdate", status: http.StatusNotFound},
+		{path: "/api/v1/images", status: http.StatusNotFound},
+		{path: "/api/v1/images/batch", status: http.StatusAccepted},
+		{path: "/api/v1/datasets:batchUpdate", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("PATCH %s status = %d, want %d", tt.path, w.Code, tt.status)
+			}
+		})
+	}
+}
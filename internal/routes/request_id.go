@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/histopathai/image-catalog-service/internal/requestid"
+)
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID
+// set by the gateway, stores it in the request context and echoes it in the
+// response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -3,24 +3,78 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/auth"
 	"github.com/histopathai/image-catalog-service/internal/handlers"
 )
 
-func SetupRouter(imageHandler *handlers.ImageHandler, gcsProxyHandler *handlers.GCSProxyHandler, cfg *config.Config) *gin.Engine {
+func SetupRouter(imageHandler *handlers.ImageHandler, aclHandler *handlers.ACLHandler, annotationHandler *handlers.AnnotationHandler, auditHandler *handlers.AuditHandler, taxonomyHandler *handlers.TaxonomyHandler, exportHandler *handlers.ExportHandler, gcsProxyHandler *handlers.GCSProxyHandler, iiifHandler *handlers.IIIFHandler, renderHandler *handlers.RenderHandler, authenticator auth.Authenticator, cfg *config.Config) *gin.Engine {
 
 	gin.SetMode(cfg.Server.GINMode)
-	router := gin.Default()
+	router := gin.New()
+	router.Use(Logger(), gin.Recovery(), RequestID())
+
+	// Dataset-level permissions are enforced by the services; these routes
+	// additionally require the global admin role.
+	adminOnly := RequireRole(auth.RoleAdmin)
+	canAnnotate := RequireRole(auth.RoleAdmin, auth.RoleAnnotator)
 
 	apiV1 := router.Group("/api/v1")
+	apiV1.Use(Authenticate(authenticator))
 	{
 		apiV1.GET("/images/:image_id", imageHandler.GetImageByID)
-		apiV1.PUT("/images/:image_id", imageHandler.UpdateImageByID)
-		apiV1.DELETE("/images/:image_id", imageHandler.DeleteImageByID)
+		apiV1.GET("/images/:image_id/dzi", imageHandler.GetImageDZI)
+		apiV1.GET("/images/:image_id/region", renderHandler.GetRegion)
+		apiV1.GET("/images/:image_id/thumbnail", renderHandler.GetThumbnail)
+		apiV1.PUT("/images/:image_id", canAnnotate, imageHandler.UpdateImageByID)
+		apiV1.PATCH("/:"+customMethodParam, CustomMethod("images:batchUpdate"), canAnnotate, imageHandler.BatchUpdateImages)
+		apiV1.DELETE("/images/:image_id", adminOnly, imageHandler.DeleteImageByID)
 		apiV1.GET("/images", imageHandler.GetImages)
+		apiV1.GET("/images/export", exportHandler.ExportImages)
+		apiV1.GET("/images/facets", imageHandler.GetImageFacets)
+		apiV1.POST("/images/import", canAnnotate, imageHandler.ImportLabels)
+		apiV1.POST("/images", adminOnly, imageHandler.CreateImage)
+		apiV1.GET("/images/trash", adminOnly, imageHandler.GetDeletedImages)
+		apiV1.POST("/images/:image_id/restore", adminOnly, imageHandler.RestoreImageByID)
+		apiV1.DELETE("/images/:image_id/cache", adminOnly, gcsProxyHandler.InvalidateImageCache) // Clears this instance's cache only
+		apiV1.GET("/cache/stats", adminOnly, gcsProxyHandler.GetCacheStats)
+
+		apiV1.GET("/images/:image_id/history", auditHandler.GetImageHistory)
+		apiV1.GET("/audit", adminOnly, auditHandler.GetAuditLog)
+
+		apiV1.GET("/images/:image_id/annotations", annotationHandler.GetAnnotations)
+		apiV1.POST("/images/:image_id/annotations", annotationHandler.CreateAnnotation)
+		apiV1.GET("/images/:image_id/annotations/:annotation_id", annotationHandler.GetAnnotationByID)
+		apiV1.PUT("/images/:image_id/annotations/:annotation_id", annotationHandler.UpdateAnnotationByID)
+		apiV1.DELETE("/images/:image_id/annotations/:annotation_id", annotationHandler.DeleteAnnotationByID)
+
+		apiV1.GET("/taxonomies", taxonomyHandler.GetTaxonomies)
+		apiV1.GET("/taxonomies/:organ_type", taxonomyHandler.GetTaxonomy)
+		apiV1.PUT("/taxonomies/:organ_type", adminOnly, taxonomyHandler.PutTaxonomy)
+		apiV1.DELETE("/taxonomies/:organ_type", adminOnly, taxonomyHandler.DeleteTaxonomy)
+
+		apiV1.GET("/datasets/:dataset_name/grants", aclHandler.GetDatasetGrants)
+		apiV1.PUT("/datasets/:dataset_name/grants", aclHandler.PutDatasetGrant)
+		apiV1.DELETE("/datasets/:dataset_name/grants", aclHandler.DeleteDatasetGrant)
 
 		// 🔥 Wildcard route to proxy all GCS objects
 		apiV1.GET("/proxy/*objectPath", gcsProxyHandler.ProxyObject)
 	}
 
+	// Signed URLs carry their own authorization, so this group has no
+	// Authenticate middleware.
+	signed := router.Group("/api/v1/signed")
+	{
+		signed.GET("/:image_id/*objectPath", gcsProxyHandler.ProxySignedObject)
+	}
+
+	// IIIF Image API 3.0
+	iiifV3 := router.Group("/iiif/3")
+	iiifV3.Use(Authenticate(authenticator))
+	{
+		iiifV3.GET("/:image_id", iiifHandler.RedirectToInfo)
+		iiifV3.GET("/:image_id/info.json", iiifHandler.GetInfo)
+		iiifV3.GET("/:image_id/:region/:size/:rotation/:quality_format", iiifHandler.GetImage)
+	}
+
 	return router
 }
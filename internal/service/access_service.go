@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// AccessService resolves and manages dataset-level permissions. Principals
+// with the global admin role bypass dataset grants entirely.
+type AccessService struct {
+	repo repository.ACLRepository
+}
+
+// NewAccessService creates a new AccessService instance.
+func NewAccessService(repo repository.ACLRepository) *AccessService {
+	return &AccessService{
+		repo: repo,
+	}
+}
+
+// DatasetAccess is the set of datasets a principal may access with a given permission.
+type DatasetAccess struct {
+	All      bool     // Every dataset, for global admins
+	Datasets []string // Sorted dataset names otherwise
+}
+
+// Allows reports whether the access covers the dataset.
+func (a *DatasetAccess) Allows(datasetName string) bool {
+	if a.All {
+		return true
+	}
+	i := sort.SearchStrings(a.Datasets, datasetName)
+	return i < len(a.Datasets) && a.Datasets[i] == datasetName
+}
+
+// Resolve returns the datasets on which the principal in ctx holds at least
+// the required permission.
+func (s *AccessService) Resolve(ctx context.Context, required string) (*DatasetAccess, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, models.NewError(models.ErrPermission, "request has no authenticated principal")
+	}
+	if principal.HasRole(auth.RoleAdmin) {
+		return &DatasetAccess{All: true}, nil
+	}
+
+	subjects := []string{models.UserSubject(principal.UserID)}
+	for _, group := range principal.Groups {
+		subjects = append(subjects, models.GroupSubject(group))
+	}
+
+	grants, err := s.repo.ListBySubjects(ctx, subjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dataset access: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	access := &DatasetAccess{Datasets: []string{}}
+	for _, grant := range grants {
+		if models.PermissionImplies(grant.Permission, required) && !seen[grant.DatasetName] {
+			seen[grant.DatasetName] = true
+			access.Datasets = append(access.Datasets, grant.DatasetName)
+		}
+	}
+	sort.Strings(access.Datasets)
+	return access, nil
+}
+
+// Check returns models.ErrPermission unless the principal in ctx holds at
+// least the required permission on the dataset.
+func (s *AccessService) Check(ctx context.Context, datasetName, required string) error {
+	access, err := s.Resolve(ctx, required)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(datasetName) {
+		return models.NewError(models.ErrPermission, "%s permission on dataset %q is required", required, datasetName)
+	}
+	return nil
+}
+
+// ListGrants returns the grants of a dataset. Requires admin permission on it.
+func (s *AccessService) ListGrants(ctx context.Context, datasetName string) ([]*models.DatasetGrant, error) {
+	if err := s.Check(ctx, datasetName, models.PermissionAdmin); err != nil {
+		return nil, err
+	}
+
+	grants, err := s.repo.ListByDataset(ctx, datasetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+	return grants, nil
+}
+
+// GrantAccess gives a subject a permission on a dataset, replacing any
+// previous grant. Requires admin permission on the dataset.
+func (s *AccessService) GrantAccess(ctx context.Context, datasetName string, req *models.DatasetGrantRequest) (*models.DatasetGrant, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Check(ctx, datasetName, models.PermissionAdmin); err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	grant := &models.DatasetGrant{
+		DatasetName: datasetName,
+		Subject:     req.Subject,
+		Permission:  req.Permission,
+		GrantedBy:   principal.UserID,
+		GrantedAt:   time.Now(),
+	}
+	if err := s.repo.Put(ctx, grant); err != nil {
+		return nil, fmt.Errorf("failed to grant access: %w", err)
+	}
+	return grant, nil
+}
+
+// RevokeAccess removes the grant of a subject on a dataset. Requires admin
+// permission on the dataset.
+func (s *AccessService) RevokeAccess(ctx context.Context, datasetName, subject string) error {
+	if err := s.Check(ctx, datasetName, models.PermissionAdmin); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, datasetName, subject); err != nil {
+		return fmt.Errorf("failed to revoke access: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/adapter"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+func newTestAccessService() *AccessService {
+	return NewAccessService(adapter.NewMemoryACLRepository(
+		&models.DatasetGrant{DatasetName: "breast", Subject: models.UserSubject("alice"), Permission: models.PermissionRead},
+		&models.DatasetGrant{DatasetName: "colon", Subject: models.UserSubject("alice"), Permission: models.PermissionAdmin},
+		&models.DatasetGrant{DatasetName: "lung", Subject: models.GroupSubject("pathology"), Permission: models.PermissionAnnotate},
+		&models.DatasetGrant{DatasetName: "breast", Subject: models.GroupSubject("pathology"), Permission: models.PermissionAnnotate},
+		&models.DatasetGrant{DatasetName: "skin", Subject: models.UserSubject("bob"), Permission: models.PermissionAdmin},
+	))
+}
+
+func TestAccessServiceResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		required  string
+		want      *DatasetAccess
+		wantErr   error
+	}{
+		{
+			name:      "global admin",
+			principal: &auth.Principal{UserID: "root", Role: auth.RoleAdmin},
+			required:  models.PermissionAdmin,
+			want:      &DatasetAccess{All: true},
+		},
+		{
+			name:      "user grants",
+			principal: &auth.Principal{UserID: "alice", Role: auth.RoleViewer},
+			required:  models.PermissionRead,
+			want:      &DatasetAccess{Datasets: []string{"breast", "colon"}},
+		},
+		{
+			name:      "stronger permission required",
+			principal: &auth.Principal{UserID: "alice", Role: auth.RoleViewer},
+			required:  models.PermissionAnnotate,
+			want:      &DatasetAccess{Datasets: []string{"colon"}},
+		},
+		{
+			name:      "user and group grants",
+			principal: &auth.Principal{UserID: "alice", Role: auth.RoleAnnotator, Groups: []string{"pathology"}},
+			required:  models.PermissionAnnotate,
+			want:      &DatasetAccess{Datasets: []string{"breast", "colon", "lung"}},
+		},
+		{
+			name:      "no grants",
+			principal: &auth.Principal{UserID: "carol", Role: auth.RoleViewer},
+			required:  models.PermissionRead,
+			want:      &DatasetAccess{Datasets: []string{}},
+		},
+		{
+			name:     "no principal",
+			required: models.PermissionRead,
+			wantErr:  models.ErrPermission,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			got, err := newTestAccessService().Resolve(ctx, tt.required)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got.All != tt.want.All || !slices.Equal(got.Datasets, tt.want.Datasets) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessServiceCheck(t *testing.T) {
+	alice := &auth.Principal{UserID: "alice", Role: auth.RoleViewer, Groups: []string{"pathology"}}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		dataset   string
+		required  string
+		allowed   bool
+	}{
+		{name: "read granted", principal: alice, dataset: "breast", required: models.PermissionRead, allowed: true},
+		{name: "annotate through group", principal: alice, dataset: "breast", required: models.PermissionAnnotate, allowed: true},
+		{name: "admin implies read", principal: alice, dataset: "colon", required: models.PermissionRead, allowed: true},
+		{name: "admin not granted", principal: alice, dataset: "lung", required: models.PermissionAdmin},
+		{name: "other user's dataset", principal: alice, dataset: "skin", required: models.PermissionRead},
+		{name: "unknown dataset", principal: alice, dataset: "kidney", required: models.PermissionRead},
+		{name: "global admin", principal: &auth.Principal{UserID: "root", Role: auth.RoleAdmin}, dataset: "kidney", required: models.PermissionAdmin, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			err := newTestAccessService().Check(ctx, tt.dataset, tt.required)
+			if tt.allowed && err != nil {
+				t.Errorf("Check() error = %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, models.ErrPermission) {
+				t.Errorf("Check() error = %v, want %v", err, models.ErrPermission)
+			}
+		})
+	}
+}
+
+func TestRestrictFilter(t *testing.T) {
+	dataset := func(name string) *string { return &name }
+	limited := &DatasetAccess{Datasets: []string{"breast", "colon"}}
+	tests := []struct {
+		name      string
+		filter    models.ImageFilter
+		access    *DatasetAccess
+		ok        bool
+		wantNames []string
+	}{
+		{name: "all datasets", access: &DatasetAccess{All: true}, ok: true},
+		{name: "all datasets with filter", filter: models.ImageFilter{DatasetName: dataset("kidney")}, access: &DatasetAccess{All: true}, ok: true},
+		{name: "restricted to granted", access: limited, ok: true, wantNames: []string{"breast", "colon"}},
+		{name: "empty dataset filter", filter: models.ImageFilter{DatasetName: dataset("")}, access: limited, ok: true, wantNames: []string{"breast", "colon"}},
+		{name: "granted dataset", filter: models.ImageFilter{DatasetName: dataset("colon")}, access: limited, ok: true},
+		{name: "other dataset", filter: models.ImageFilter{DatasetName: dataset("kidney")}, access: limited},
+		{name: "no grants", access: &DatasetAccess{Datasets: []string{}}},
+		{name: "no grants with filter", filter: models.ImageFilter{DatasetName: dataset("colon")}, access: &DatasetAccess{Datasets: []string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			if ok := restrictFilter(&filter, tt.access); ok != tt.ok {
+				t.Fatalf("restrictFilter() = %t, want %t", ok, tt.ok)
+			}
+			if tt.ok && !slices.Equal(filter.DatasetNames, tt.wantNames) {
+				t.Errorf("DatasetNames = %v, want %v", filter.DatasetNames, tt.wantNames)
+			}
+		})
+	}
+}
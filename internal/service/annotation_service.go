@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// AnnotationService manages the regions and points drawn on slides. Reading
+// annotations requires read permission on the image's dataset and drawing
+// them requires annotate permission. Annotations can only be changed by
+// their author or a dataset admin.
+type AnnotationService struct {
+	repo   repository.AnnotationRepository
+	images repository.ImageRepository
+	access *AccessService
+}
+
+// NewAnnotationService creates a new AnnotationService instance.
+func NewAnnotationService(repo repository.AnnotationRepository, images repository.ImageRepository, access *AccessService) *AnnotationService {
+	return &AnnotationService{
+		repo:   repo,
+		images: images,
+		access: access,
+	}
+}
+
+// ListAnnotations returns every annotation of an image, oldest first.
+func (s *AnnotationService) ListAnnotations(ctx context.Context, imageID string) ([]*models.Annotation, error) {
+	if _, err := s.readImage(ctx, imageID, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	annotations, err := s.repo.ListByImage(ctx, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+// GetAnnotation returns one annotation of an image.
+func (s *AnnotationService) GetAnnotation(ctx context.Context, imageID, annotationID string) (*models.Annotation, error) {
+	if _, err := s.readImage(ctx, imageID, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	annotation, err := s.repo.Read(ctx, imageID, annotationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve annotation: %w", err)
+	}
+	return annotation, nil
+}
+
+// CreateAnnotation draws a new annotation on an image on behalf of the
+// principal in ctx.
+func (s *AnnotationService) CreateAnnotation(ctx context.Context, imageID string, req *models.AnnotationCreateRequest) (*models.Annotation, error) {
+	image, err := s.readImage(ctx, imageID, models.PermissionAnnotate)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(image.Width, image.Height); err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	now := time.Now()
+	annotation := &models.Annotation{
+		ImageID:   imageID,
+		Label:     req.Label,
+		Geometry:  req.Geometry,
+		CreatedBy: principal.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, annotation); err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+	return annotation, nil
+}
+
+// UpdateAnnotation changes the label or geometry of an annotation.
+func (s *AnnotationService) UpdateAnnotation(ctx context.Context, imageID, annotationID string, req *models.AnnotationUpdateRequest) (*models.Annotation, error) {
+	image, annotation, err := s.readOwned(ctx, imageID, annotationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(image.Width, image.Height); err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		annotation.Label = *req.Label
+	}
+	if req.Geometry != nil {
+		annotation.Geometry = *req.Geometry
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	annotation.UpdatedBy = principal.UserID
+	annotation.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, annotation); err != nil {
+		return nil, fmt.Errorf("failed to update annotation: %w", err)
+	}
+	return annotation, nil
+}
+
+// DeleteAnnotation removes an annotation.
+func (s *AnnotationService) DeleteAnnotation(ctx context.Context, imageID, annotationID string) error {
+	if _, _, err := s.readOwned(ctx, imageID, annotationID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, imageID, annotationID); err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+	return nil
+}
+
+// readImage returns an active image on whose dataset the principal in ctx
+// holds the required permission.
+func (s *AnnotationService) readImage(ctx context.Context, imageID, required string) (*models.Image, error) {
+	image, err := s.images.Read(ctx, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve image: %w", err)
+	}
+	if err := s.access.Check(ctx, image.DatasetName, required); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// readOwned returns an annotation the principal in ctx may change: one they
+// drew while still holding annotate permission, or any annotation of a
+// dataset they administer.
+func (s *AnnotationService) readOwned(ctx context.Context, imageID, annotationID string) (*models.Image, *models.Annotation, error) {
+	image, err := s.readImage(ctx, imageID, models.PermissionAnnotate)
+	if err != nil {
+		return nil, nil, err
+	}
+	annotation, err := s.repo.Read(ctx, imageID, annotationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve annotation: %w", err)
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if annotation.CreatedBy != principal.UserID {
+		if err := s.access.Check(ctx, image.DatasetName, models.PermissionAdmin); err != nil {
+			return nil, nil, models.NewError(models.ErrPermission, "only the author or a dataset admin may change annotation %q", annotationID)
+		}
+	}
+	return image, annotation, nil
+}
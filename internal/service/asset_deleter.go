@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// AssetDeletionError reports the objects that could not be removed while
+// deleting the files of an image.
+type AssetDeletionError struct {
+	Deleted int
+	Failed  map[string]error
+}
+
+func (e *AssetDeletionError) Error() string {
+	return fmt.Sprintf("failed to delete %d of %d image assets", len(e.Failed), e.Deleted+len(e.Failed))
+}
+
+// FailedObjects returns the names of the objects that could not be deleted, sorted.
+func (e *AssetDeletionError) FailedObjects() []string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AssetDeleter removes the tile pyramid, DZI descriptor, thumbnail and
+// derived objects of an image from object storage with bounded concurrency and per-object retries.
+type AssetDeleter struct {
+	store       repository.ObjectStore
+	images      repository.ImageRepository
+	concurrency int
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewAssetDeleter(store repository.ObjectStore, images repository.ImageRepository, concurrency, maxAttempts int, backoff time.Duration) *AssetDeleter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &AssetDeleter{
+		store:       store,
+		images:      images,
+		concurrency: concurrency,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// DeleteImageAssets deletes every object that belongs to the image,
+// including the ones derived from it by the service. If some objects cannot
+// be removed it returns an *AssetDeletionError listing them. The tiles
+// prefix is checked with checkTilesPrefix before anything is listed.
+func (d *AssetDeleter) DeleteImageAssets(ctx context.Context, image *models.Image) error {
+	var prefixes []string
+	if tiles := models.ObjectName(image.TilesGCSPath); tiles != "" {
+		prefix := strings.TrimSuffix(tiles, "/") + "/"
+		if err := d.checkTilesPrefix(ctx, image, prefix); err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	prefixes = append(prefixes, image.DerivedPrefix())
+
+	names, err := d.collect(ctx, prefixes, models.ObjectName(image.DZIGCSPath), models.ObjectName(image.ThumbnailGCSPath))
+	if err != nil {
+		return err
+	}
+	return d.deleteObjects(ctx, image, names)
+}
+
+// checkTilesPrefix refuses a tiles prefix that may hold objects of other
+// images, since the prefix comes from the client that registered the image.
+// The prefix must name the image, with a segment equal to its ID or file
+// UID, and no other record, active or in the trash, may have assets under it
+// or a tiles prefix that contains it.
+func (d *AssetDeleter) checkTilesPrefix(ctx context.Context, image *models.Image, prefix string) error {
+	segments := strings.Split(strings.TrimSuffix(prefix, "/"), "/")
+	if !slices.Contains(segments, image.ID) && (image.FileUID == "" || !slices.Contains(segments, image.FileUID)) {
+		return models.NewError(models.ErrConflict, "tiles prefix %q of image %q names neither its ID nor its file UID", prefix, image.ID)
+	}
+	others, err := d.images.ListOverlapping(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to check tiles prefix: %w", err)
+	}
+	for _, other := range others {
+		if other.ID != image.ID {
+			return models.NewError(models.ErrConflict, "tiles prefix %q of image %q is shared with image %q", prefix, image.ID, other.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteDerivedAssets deletes the objects the service derived from the
+// image, such as resized thumbnails, so that they are generated again.
+func (d *AssetDeleter) DeleteDerivedAssets(ctx context.Context, image *models.Image) error {
+	names, err := d.collect(ctx, []string{image.DerivedPrefix()})
+	if err != nil {
+		return err
+	}
+	return d.deleteObjects(ctx, image, names)
+}
+
+// deleteObjects deletes the named objects of an image in parallel.
+func (d *AssetDeleter) deleteObjects(ctx context.Context, image *models.Image, names []string) error {
+	jobs := make(chan string)
+	var mu sync.Mutex
+	result := &AssetDeletionError{Failed: make(map[string]error)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				err := d.retry(ctx, func() error { return d.store.Delete(ctx, name) })
+				mu.Lock()
+				if err != nil {
+					result.Failed[name] = err
+				} else {
+					result.Deleted++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range names {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(result.Failed) > 0 {
+		slog.Warn("Failed to delete some image assets", "image_id", image.ID, "failed", len(result.Failed), "deleted", result.Deleted)
+		return result
+	}
+	slog.Info("Deleted image assets", "image_id", image.ID, "deleted", result.Deleted)
+	return nil
+}
+
+// collect lists the objects under the prefixes and adds the named objects.
+func (d *AssetDeleter) collect(ctx context.Context, prefixes []string, objects ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, prefix := range prefixes {
+		var listed []string
+		err := d.retry(ctx, func() error {
+			var err error
+			listed, err = d.store.List(ctx, prefix)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list image assets: %w", err)
+		}
+		for _, name := range listed {
+			add(name)
+		}
+	}
+	for _, name := range objects {
+		add(name)
+	}
+
+	return names, nil
+}
+
+// retry runs fn until it succeeds, the attempts are exhausted or the context
+// is cancelled, doubling the wait between attempts.
+func (d *AssetDeleter) retry(ctx context.Context, fn func() error) error {
+	var err error
+	wait := d.backoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == d.maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"github.com/histopathai/image-catalog-service/internal/requestid"
+)
+
+// AuditService records changes to images and answers queries over them.
+type AuditService struct {
+	repo   repository.AuditRepository
+	images repository.ImageRepository
+	access *AccessService
+}
+
+// NewAuditService creates a new AuditService instance.
+func NewAuditService(repo repository.AuditRepository, images repository.ImageRepository, access *AccessService) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		images: images,
+		access: access,
+	}
+}
+
+// Entry returns an audit entry for an action on an image, attributed to the
+// principal and request in ctx, or to the system when there is no principal.
+func (s *AuditService) Entry(ctx context.Context, image *models.Image, action string, at time.Time) *models.AuditEntry {
+	actor := models.AuditActorSystem
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		actor = principal.UserID
+	}
+	return &models.AuditEntry{
+		ImageID:     image.ID,
+		DatasetName: image.DatasetName,
+		Action:      action,
+		Actor:       actor,
+		Timestamp:   at,
+		RequestID:   requestid.FromContext(ctx),
+	}
+}
+
+// ImageHistory returns a page of the audit entries of an image, newest
+// first. Requires read permission on the image's dataset.
+func (s *AuditService) ImageHistory(ctx context.Context, imageID string, filter *models.AuditFilter) (*models.AuditList, error) {
+	image, err := s.images.Read(ctx, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve image: %w", err)
+	}
+	if err := s.access.Check(ctx, image.DatasetName, models.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	filter.ImageID = imageID
+	return s.Query(ctx, filter)
+}
+
+// Query returns a page of the audit entries matching the filter, newest
+// first. Callers are responsible for restricting it to global admins.
+func (s *AuditService) Query(ctx context.Context, filter *models.AuditFilter) (*models.AuditList, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.Query(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// BatchUpdateImages applies one label update to the images selected by ID or
+// by filter. Each image is only written if it has not changed since it was
+// selected, and the result of every image is reported separately. A dry run
+// reports the changes without writing them.
+func (s *ImageService) BatchUpdateImages(ctx context.Context, req *models.ImageBatchUpdateRequest) (*models.ImageBatchUpdateResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	access, err := s.access.Resolve(ctx, models.PermissionAnnotate)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ImageBatchUpdateResponse{DryRun: req.DryRun, Results: []*models.ImageBatchResult{}}
+	var images []*models.Image
+	if req.Filter != nil {
+		if images, err = s.selectByFilter(ctx, req.Filter, access); err != nil {
+			return nil, err
+		}
+	} else {
+		if images, err = s.selectByIDs(ctx, req.IDs, access, response); err != nil {
+			return nil, err
+		}
+	}
+
+	// Work out the changes, leaving out images that already have the labels
+	// and images whose taxonomy does not allow them.
+	resolver := s.taxonomies.Resolver()
+	var pending []labelUpdate
+	for _, image := range images {
+		result := &models.ImageBatchResult{ID: image.ID, Status: models.BatchStatusUnchanged}
+		response.Results = append(response.Results, result)
+		labels, err := resolver.Resolve(ctx, image, image.OrganType, &req.Update)
+		if errors.Is(err, models.ErrValidation) {
+			result.Status, result.Error = models.BatchStatusInvalid, err.Error()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result.Changes = labels.LabelChanges(image); len(result.Changes) == 0 {
+			continue
+		}
+		if req.DryRun {
+			result.Status = models.BatchStatusWouldUpdate
+			continue
+		}
+		pending = append(pending, labelUpdate{image: image, labels: labels, changes: result.Changes})
+	}
+
+	outcomes := s.writeLabels(ctx, pending)
+	for _, result := range response.Results {
+		if outcome, ok := outcomes[result.ID]; ok {
+			result.Status, result.Version, result.Error = outcome.Status, outcome.Version, outcome.Error
+		}
+		switch result.Status {
+		case models.BatchStatusUpdated, models.BatchStatusWouldUpdate:
+			response.Updated++
+		case models.BatchStatusUnchanged:
+		default:
+			response.Failed++
+		}
+	}
+	response.Matched = len(response.Results)
+	return response, nil
+}
+
+// labelUpdate is a pending write of label changes to one image.
+type labelUpdate struct {
+	image   *models.Image
+	labels  *models.ImageUpdateRequest
+	changes []models.FieldChange
+}
+
+// writeLabels writes the updates in rounds of models.MaxBatchWrites images,
+// each together with the audit entries of its changes and only if it is still
+// at the version it was read at. It returns the outcome of every image by ID.
+func (s *ImageService) writeLabels(ctx context.Context, updates []labelUpdate) map[string]*models.ImageBatchResult {
+	now := time.Now()
+	outcomes := make(map[string]*models.ImageBatchResult, len(updates))
+	for start := 0; start < len(updates); start += models.MaxBatchWrites {
+		chunk := updates[start:min(start+models.MaxBatchWrites, len(updates))]
+		images := make([]*models.Image, len(chunk))
+		var entries []*models.AuditEntry
+		for i, update := range chunk {
+			entries = append(entries, s.changeEntries(ctx, update.image, update.changes, now)...)
+			update.labels.ApplyLabels(update.image)
+			update.image.UpdatedAt = now
+			images[i] = update.image
+		}
+
+		skipped, err := s.repo.UpdateBatch(ctx, images, entries)
+		for _, update := range chunk {
+			image := update.image
+			outcome := &models.ImageBatchResult{ID: image.ID}
+			switch {
+			case err != nil:
+				outcome.Status, outcome.Error = models.BatchStatusFailed, err.Error()
+			case errors.Is(skipped[image.ID], models.ErrPrecondition):
+				outcome.Status, outcome.Error = models.BatchStatusConflict, skipped[image.ID].Error()
+			case errors.Is(skipped[image.ID], models.ErrNotFound):
+				outcome.Status, outcome.Error = models.BatchStatusNotFound, skipped[image.ID].Error()
+			case skipped[image.ID] != nil:
+				outcome.Status, outcome.Error = models.BatchStatusFailed, skipped[image.ID].Error()
+			default:
+				outcome.Status, outcome.Version = models.BatchStatusUpdated, image.Version
+			}
+			outcomes[image.ID] = outcome
+		}
+	}
+	return outcomes
+}
+
+// selectByIDs reads the images with the given IDs. Missing images and images
+// the caller may not annotate are added to the response as failures.
+func (s *ImageService) selectByIDs(ctx context.Context, ids []string, access *DatasetAccess, response *models.ImageBatchUpdateResponse) ([]*models.Image, error) {
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	found, err := s.repo.ReadMany(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images: %w", err)
+	}
+	byID := make(map[string]*models.Image, len(found))
+	for _, image := range found {
+		byID[image.ID] = image
+	}
+
+	var images []*models.Image
+	for _, id := range ids {
+		image, ok := byID[id]
+		switch {
+		case !ok:
+			response.Results = append(response.Results, &models.ImageBatchResult{ID: id, Status: models.BatchStatusNotFound, Error: fmt.Sprintf("image %q not found", id)})
+		case !access.Allows(image.DatasetName):
+			response.Results = append(response.Results, &models.ImageBatchResult{ID: id, Status: models.BatchStatusForbidden, Error: fmt.Sprintf("%s permission on dataset %q is required", models.PermissionAnnotate, image.DatasetName)})
+		default:
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// selectByFilter returns every image matching the filter in the datasets
+// the caller may annotate, failing if there are more than models.MaxBatchImages.
+func (s *ImageService) selectByFilter(ctx context.Context, selector *models.ImageFilter, access *DatasetAccess) ([]*models.Image, error) {
+	filter := &models.ImageFilter{
+		DatasetName:    selector.DatasetName,
+		OrganType:      selector.OrganType,
+		DiseaseType:    selector.DiseaseType,
+		Classification: selector.Classification,
+		SubType:        selector.SubType,
+		Grade:          selector.Grade,
+		Limit:          models.MaxPageSize,
+		SortBy:         models.SortByCreatedAt,
+		Order:          models.OrderAsc,
+	}
+	if !restrictFilter(filter, access) {
+		return nil, nil
+	}
+
+	var images []*models.Image
+	for {
+		page, err := s.repo.Filter(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select images: %w", err)
+		}
+		images = append(images, page.Images...)
+		if len(images) > models.MaxBatchImages || page.TotalCount > models.MaxBatchImages {
+			return nil, models.NewError(models.ErrValidation, "filter matches more than %d images; narrow it down", models.MaxBatchImages)
+		}
+		if page.NextPageToken == "" {
+			return images, nil
+		}
+		filter.PageToken = page.NextPageToken
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/adapter"
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
+)
+
+// testImageService is an ImageService on the in-memory repositories, in
+// which the annotator "ann" may annotate the "breast" dataset.
+type testImageService struct {
+	*ImageService
+	images *adapter.MemoryImageRepository
+	audit  *adapter.MemoryAuditRepository
+}
+
+func newTestImageService(t *testing.T, taxonomies []*models.Taxonomy, images ...*models.Image) *testImageService {
+	t.Helper()
+	store, err := adapter.NewLocalObjectStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Storage: config.StorageConfig{DeleteConcurrency: 1, DeleteMaxAttempts: 1}}
+	auditRepo := adapter.NewMemoryAuditRepository()
+	imageRepo := adapter.NewMemoryImageRepository(auditRepo, images...)
+	access := NewAccessService(adapter.NewMemoryACLRepository(
+		&models.DatasetGrant{DatasetName: "breast", Subject: models.UserSubject("ann"), Permission: models.PermissionAnnotate},
+		&models.DatasetGrant{DatasetName: "colon", Subject: models.UserSubject("ann"), Permission: models.PermissionRead},
+	))
+	svc := NewImageService(imageRepo, adapter.NewMemoryAnnotationRepository(), NewAuditService(auditRepo, imageRepo, access), store, access,
+		NewTaxonomyService(adapter.NewMemoryTaxonomyRepository(taxonomies...)), tilecache.New(0, 0, 1<<20, tilecache.NewMemoryTier(1<<20)), cfg)
+	return &testImageService{ImageService: svc, images: imageRepo, audit: auditRepo}
+}
+
+func annotatorContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{UserID: "ann", Role: auth.RoleAnnotator})
+}
+
+func label(value string) *string { return &value }
+
+func batchImages() []*models.Image {
+	return []*models.Image{
+		{ID: "b1", DatasetName: "breast", OrganType: "breast", DiseaseType: label("carcinoma"), Version: 1},
+		{ID: "b2", DatasetName: "breast", OrganType: "breast", DiseaseType: label("carcinoma"), SubType: label("ductal"), Version: 3},
+		{ID: "b3", DatasetName: "breast", OrganType: "lung", DiseaseType: label("adenocarcinoma"), Version: 1},
+		{ID: "c1", DatasetName: "colon", OrganType: "colon", DiseaseType: label("carcinoma"), Version: 1},
+	}
+}
+
+var lungTaxonomy = &models.Taxonomy{
+	OrganType: "lung",
+	Diseases: []models.DiseaseTerm{{
+		Term:     models.Term{Value: "adenocarcinoma"},
+		SubTypes: []models.SubTypeTerm{{Term: models.Term{Value: "acinar"}}},
+	}},
+}
+
+func TestBatchUpdateImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.ImageBatchUpdateRequest
+		want    map[string]string // Status by image ID
+		updated int
+		failed  int
+	}{
+		{
+			name: "by ids",
+			req:  models.ImageBatchUpdateRequest{IDs: []string{"b1", "b2", "b3", "c1", "missing", "b1"}, Update: models.ImageUpdateRequest{SubType: label("ductal")}},
+			want: map[string]string{
+				"b1":      models.BatchStatusUpdated,
+				"b2":      models.BatchStatusUnchanged,
+				"b3":      models.BatchStatusInvalid,
+				"c1":      models.BatchStatusForbidden,
+				"missing": models.BatchStatusNotFound,
+			},
+			updated: 1,
+			failed:  3,
+		},
+		{
+			name:    "dry run",
+			req:     models.ImageBatchUpdateRequest{IDs: []string{"b1", "b2"}, Update: models.ImageUpdateRequest{SubType: label("ductal")}, DryRun: true},
+			want:    map[string]string{"b1": models.BatchStatusWouldUpdate, "b2": models.BatchStatusUnchanged},
+			updated: 1,
+		},
+		{
+			name:    "by filter",
+			req:     models.ImageBatchUpdateRequest{Filter: &models.ImageFilter{DiseaseType: label("carcinoma")}, Update: models.ImageUpdateRequest{Grade: label("2")}},
+			want:    map[string]string{"b1": models.BatchStatusUpdated, "b2": models.BatchStatusUpdated},
+			updated: 2,
+		},
+		{
+			name: "filter outside granted datasets",
+			req:  models.ImageBatchUpdateRequest{Filter: &models.ImageFilter{DatasetName: label("colon")}, Update: models.ImageUpdateRequest{Grade: label("2")}},
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestImageService(t, []*models.Taxonomy{lungTaxonomy}, batchImages()...)
+			before := readAll(t, svc)
+
+			resp, err := svc.BatchUpdateImages(annotatorContext(), &tt.req)
+			if err != nil {
+				t.Fatalf("BatchUpdateImages() error = %v", err)
+			}
+			got := make(map[string]string)
+			for _, result := range resp.Results {
+				got[result.ID] = result.Status
+			}
+			if len(got) != len(tt.want) || len(resp.Results) != len(tt.want) {
+				t.Fatalf("results = %v, want %v", got, tt.want)
+			}
+			for id, status := range tt.want {
+				if got[id] != status {
+					t.Errorf("status of %s = %q, want %q", id, got[id], status)
+				}
+			}
+			if resp.Matched != len(tt.want) || resp.Updated != tt.updated || resp.Failed != tt.failed {
+				t.Errorf("matched, updated, failed = %d, %d, %d, want %d, %d, %d", resp.Matched, resp.Updated, resp.Failed, len(tt.want), tt.updated, tt.failed)
+			}
+
+			// Only updated images change, each to the next version with an
+			// audit entry per changed field.
+			after := readAll(t, svc)
+			for id, image := range after {
+				wantVersion, wantEntries := before[id].Version, 0
+				if tt.want[id] == models.BatchStatusUpdated {
+					wantVersion, wantEntries = wantVersion+1, 1
+				}
+				if image.Version != wantVersion {
+					t.Errorf("version of %s = %d, want %d", id, image.Version, wantVersion)
+				}
+				entries, err := svc.audit.Query(context.Background(), &models.AuditFilter{ImageID: id, Limit: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries.Entries) != wantEntries {
+					t.Errorf("audit entries of %s = %d, want %d", id, len(entries.Entries), wantEntries)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchUpdateImagesInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.ImageBatchUpdateRequest
+	}{
+		{name: "no selector", req: models.ImageBatchUpdateRequest{Update: models.ImageUpdateRequest{Grade: label("1")}}},
+		{name: "both selectors", req: models.ImageBatchUpdateRequest{IDs: []string{"b1"}, Filter: &models.ImageFilter{Grade: label("1")}, Update: models.ImageUpdateRequest{Grade: label("2")}}},
+		{name: "empty filter", req: models.ImageBatchUpdateRequest{Filter: &models.ImageFilter{}, Update: models.ImageUpdateRequest{Grade: label("2")}}},
+		{name: "no labels", req: models.ImageBatchUpdateRequest{IDs: []string{"b1"}}},
+		{name: "organ type", req: models.ImageBatchUpdateRequest{IDs: []string{"b1"}, Update: models.ImageUpdateRequest{OrganType: label("lung")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestImageService(t, nil, batchImages()...)
+			if _, err := svc.BatchUpdateImages(annotatorContext(), &tt.req); !errors.Is(err, models.ErrValidation) {
+				t.Errorf("BatchUpdateImages() error = %v, want %v", err, models.ErrValidation)
+			}
+		})
+	}
+}
+
+// readAll returns the stored images of the test service by ID.
+func readAll(t *testing.T, svc *testImageService) map[string]*models.Image {
+	t.Helper()
+	images, err := svc.images.ReadMany(context.Background(), []string{"b1", "b2", "b3", "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]*models.Image, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+	return byID
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxFacetCounts bounds the count queries run for one facet field; fields
+	// with more known values are counted by reading the images instead.
+	maxFacetCounts = 50
+	// facetCountConcurrency bounds the count queries running at once.
+	facetCountConcurrency = 8
+	// maxFacetScan bounds the images read for the fields that are not
+	// counted with count queries.
+	maxFacetScan = 10000
+)
+
+// errFacetScanLimit stops the scan of the images once maxFacetScan were read.
+var errFacetScanLimit = errors.New("facet scan limit reached")
+
+// facetCount is a count query for one value of a facet field.
+type facetCount struct {
+	facet int
+	value string
+	count int64
+}
+
+// ImageFacets counts the images matching the filter that the caller may
+// read by the values of each field. Where the values a field can take are
+// known, each one is counted with a count query, which the repository
+// answers without reading the images. The values are known exhaustively if
+// the filter fixes the field or it is the datasets a non-admin caller may
+// read; organ types and diseases are taken from the taxonomies, so images
+// labelled outside them are only counted in Other. The remaining fields are
+// counted in one pass over at most maxFacetScan matching images; if more
+// match, the facets report how many were read in Sampled.
+func (s *ImageService) ImageFacets(ctx context.Context, filter *models.ImageFilter, fields []string) (*models.FacetList, error) {
+	filter.Limit, filter.PageToken = 0, ""
+	if err := filter.NormalizePagination(); err != nil {
+		return nil, err
+	}
+	list := &models.FacetList{Facets: make([]*models.Facet, len(fields))}
+	for i, field := range fields {
+		list.Facets[i] = &models.Facet{Field: field, Buckets: []models.FacetBucket{}}
+	}
+
+	access, err := s.access.Resolve(ctx, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if !restrictFilter(filter, access) {
+		return list, nil
+	}
+	var taxonomies []*models.Taxonomy
+	if slices.Contains(fields, "organ_type") || slices.Contains(fields, "disease_type") {
+		if taxonomies, err = s.taxonomies.ListTaxonomies(ctx); err != nil {
+			return nil, err
+		}
+	}
+	known := facetValues(filter, access, taxonomies, fields)
+
+	var queries []*facetCount
+	var scanned []int
+	for i, field := range fields {
+		if values := known[field]; len(values) > 0 && len(values) <= maxFacetCounts {
+			for _, value := range values {
+				queries = append(queries, &facetCount{facet: i, value: value})
+			}
+		} else {
+			scanned = append(scanned, i)
+		}
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(facetCountConcurrency)
+	group.Go(func() (err error) {
+		list.Total, err = s.repo.Count(groupCtx, filter)
+		return err
+	})
+	for _, query := range queries {
+		group.Go(func() (err error) {
+			query.count, err = s.repo.Count(groupCtx, filter.WithFacetValue(fields[query.facet], query.value))
+			return err
+		})
+	}
+	counts := make([]map[string]int64, len(fields))
+	var read int64
+	truncated := false
+	if len(scanned) > 0 {
+		for _, i := range scanned {
+			counts[i] = make(map[string]int64)
+		}
+		group.Go(func() error {
+			err := s.repo.Iterate(groupCtx, filter, func(image *models.Image) error {
+				if read == maxFacetScan {
+					return errFacetScanLimit
+				}
+				read++
+				for _, i := range scanned {
+					if value := image.FacetValue(fields[i]); value != "" {
+						counts[i][value]++
+					}
+				}
+				return nil
+			})
+			if errors.Is(err, errFacetScanLimit) {
+				truncated = true
+				return nil
+			}
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to count images: %w", err)
+	}
+
+	for _, query := range queries {
+		if query.count > 0 {
+			facet := list.Facets[query.facet]
+			facet.Buckets = append(facet.Buckets, models.FacetBucket{Value: query.value, Count: query.count})
+		}
+	}
+	for _, i := range scanned {
+		for value, count := range counts[i] {
+			list.Facets[i].Buckets = append(list.Facets[i].Buckets, models.FacetBucket{Value: value, Count: count})
+		}
+		if truncated {
+			list.Facets[i].Sampled = read
+		}
+	}
+	for _, facet := range list.Facets {
+		slices.SortFunc(facet.Buckets, func(a, b models.FacetBucket) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+		})
+		// Images changed between the queries can make the buckets add up to
+		// more than the total.
+		facet.Other = list.Total
+		if facet.Sampled > 0 {
+			facet.Other = facet.Sampled
+		}
+		for _, bucket := range facet.Buckets {
+			facet.Other -= bucket.Count
+		}
+		facet.Other = max(facet.Other, 0)
+	}
+	return list, nil
+}
+
+// facetValues returns the values each field can have among the images
+// matching the filter, where they are known: the value the filter requires,
+// the datasets a non-admin caller may read, and the organ types and diseases
+// of the taxonomies. Fields whose values are not known are left out.
+func facetValues(filter *models.ImageFilter, access *DatasetAccess, taxonomies []*models.Taxonomy, fields []string) map[string][]string {
+	known := make(map[string][]string, len(fields))
+	for _, field := range fields {
+		switch {
+		case filter.FacetValue(field) != "":
+			known[field] = []string{filter.FacetValue(field)}
+		case field == "dataset_name" && !access.All:
+			known[field] = access.Datasets
+		case field == "organ_type":
+			for _, taxonomy := range taxonomies {
+				known[field] = append(known[field], taxonomy.OrganType)
+			}
+		case field == "disease_type":
+			for _, taxonomy := range taxonomies {
+				if organType := filter.FacetValue("organ_type"); organType != "" && taxonomy.OrganType != organType {
+					continue
+				}
+				for _, disease := range taxonomy.Diseases {
+					if !slices.Contains(known[field], disease.Value) {
+						known[field] = append(known[field], disease.Value)
+					}
+				}
+			}
+		}
+	}
+	return known
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/histopathai/image-catalog-service/adapter"
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// scanCountingRepository counts the scans of the images.
+type scanCountingRepository struct {
+	*adapter.MemoryImageRepository
+	scans int
+}
+
+func (r *scanCountingRepository) Iterate(ctx context.Context, filter *models.ImageFilter, fn func(*models.Image) error) error {
+	r.scans++
+	return r.MemoryImageRepository.Iterate(ctx, filter, fn)
+}
+
+var breastTaxonomy = &models.Taxonomy{
+	OrganType: "breast",
+	Diseases: []models.DiseaseTerm{
+		{Term: models.Term{Value: "carcinoma"}},
+		{Term: models.Term{Value: "fibroadenoma"}},
+	},
+}
+
+func adminContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{UserID: "root", Role: auth.RoleAdmin})
+}
+
+func TestImageFacets(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		taxonomies []*models.Taxonomy
+		filter     models.ImageFilter
+		field      string
+		want       map[string]int64
+		other      int64
+		scan       bool
+	}{
+		{
+			name:       "organ types from the taxonomies",
+			ctx:        adminContext(),
+			taxonomies: []*models.Taxonomy{breastTaxonomy, lungTaxonomy},
+			field:      "organ_type",
+			want:       map[string]int64{"breast": 2, "lung": 1},
+			other:      1, // colon has no taxonomy
+		},
+		{
+			name:       "diseases from the taxonomies",
+			ctx:        adminContext(),
+			taxonomies: []*models.Taxonomy{breastTaxonomy, lungTaxonomy},
+			field:      "disease_type",
+			want:       map[string]int64{"carcinoma": 3, "adenocarcinoma": 1},
+		},
+		{
+			name:       "diseases of the filtered organ type",
+			ctx:        adminContext(),
+			taxonomies: []*models.Taxonomy{breastTaxonomy, lungTaxonomy},
+			filter:     models.ImageFilter{OrganType: label("lung")},
+			field:      "disease_type",
+			want:       map[string]int64{"adenocarcinoma": 1},
+		},
+		{
+			name:  "organ types without taxonomies",
+			ctx:   adminContext(),
+			field: "organ_type",
+			want:  map[string]int64{"breast": 2, "lung": 1, "colon": 1},
+			scan:  true,
+		},
+		{
+			name:       "labels without known values",
+			ctx:        adminContext(),
+			taxonomies: []*models.Taxonomy{breastTaxonomy},
+			field:      "sub_type",
+			want:       map[string]int64{"ductal": 1},
+			other:      3,
+			scan:       true,
+		},
+		{
+			name:  "readable datasets",
+			ctx:   annotatorContext(),
+			field: "dataset_name",
+			want:  map[string]int64{"breast": 3, "colon": 1},
+		},
+		{
+			name:   "fixed by the filter",
+			ctx:    annotatorContext(),
+			filter: models.ImageFilter{Grade: label("2")},
+			field:  "grade",
+			want:   map[string]int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestImageService(t, tt.taxonomies, batchImages()...)
+			repo := &scanCountingRepository{MemoryImageRepository: svc.images}
+			svc.repo = repo
+
+			list, err := svc.ImageFacets(tt.ctx, &tt.filter, []string{tt.field})
+			if err != nil {
+				t.Fatal(err)
+			}
+			facet := list.Facets[0]
+			got := make(map[string]int64)
+			for _, bucket := range facet.Buckets {
+				got[bucket.Value] = bucket.Count
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || facet.Other != tt.other {
+				t.Errorf("buckets = %v, other %d, want %v, other %d", got, facet.Other, tt.want, tt.other)
+			}
+			if (repo.scans > 0) != tt.scan {
+				t.Errorf("scans = %d, want a scan: %v", repo.scans, tt.scan)
+			}
+			if facet.Sampled != 0 {
+				t.Errorf("sampled = %d, want 0", facet.Sampled)
+			}
+		})
+	}
+}
+
+func TestImageFacetsScanLimit(t *testing.T) {
+	images := make([]*models.Image, maxFacetScan+1)
+	for i := range images {
+		images[i] = &models.Image{ID: fmt.Sprintf("img-%05d", i), DatasetName: "breast", OrganType: "breast", Grade: label("1")}
+	}
+	svc := newTestImageService(t, nil, images...)
+
+	list, err := svc.ImageFacets(adminContext(), &models.ImageFilter{}, []string{"grade", "dataset_name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != maxFacetScan+1 {
+		t.Errorf("total = %d, want %d", list.Total, maxFacetScan+1)
+	}
+	for _, facet := range list.Facets {
+		if facet.Sampled != maxFacetScan || len(facet.Buckets) != 1 || facet.Buckets[0].Count != maxFacetScan || facet.Other != 0 {
+			t.Errorf("facet %s = %+v, want %d sampled images in one bucket", facet.Field, facet, maxFacetScan)
+		}
+	}
+}
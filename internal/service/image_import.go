@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+)
+
+// importLookupSize is the number of keys looked up per repository call.
+const importLookupSize = 500
+
+// ImportLabels sets the labels listed in a manifest on the images its rows
+// match by file UID or file name. Rows that are invalid, match no image or
+// several images, whose image the caller may not annotate or whose labels
+// the image's taxonomy does not allow are reported and skipped; the other
+// images are written as in BatchUpdateImages. A dry run reports the changes
+// without writing them.
+func (s *ImageService) ImportLabels(ctx context.Context, req *models.ImportRequest) (*models.ImportReport, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	access, err := s.access.Resolve(ctx, models.PermissionAnnotate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: req.DryRun, Rows: len(req.Rows), Results: make([]*models.ImportRowResult, len(req.Rows))}
+	firstLine := make(map[string]int)
+	var keys []string
+	for i, row := range req.Rows {
+		result := &models.ImportRowResult{Line: row.Line, Key: row.Key}
+		report.Results[i] = result
+		switch {
+		case row.Error != "":
+			result.Error = row.Error
+		case row.Key == "":
+			result.Error = fmt.Sprintf("%s is empty", req.MatchBy)
+		case !row.Labels.HasLabels():
+			result.Error = "row sets no labels"
+		case firstLine[row.Key] != 0:
+			result.Error = fmt.Sprintf("duplicate of line %d", firstLine[row.Key])
+		default:
+			if err := row.Labels.ValidateLabels(); err != nil {
+				result.Error = err.Error()
+				break
+			}
+			firstLine[row.Key] = row.Line
+			keys = append(keys, row.Key)
+		}
+		if result.Error != "" {
+			result.Status = models.BatchStatusInvalid
+		}
+	}
+
+	matches := make(map[string][]*models.Image, len(keys))
+	for start := 0; start < len(keys); start += importLookupSize {
+		images, err := s.repo.ListByField(ctx, req.MatchBy, keys[start:min(start+importLookupSize, len(keys))])
+		if err != nil {
+			return nil, fmt.Errorf("failed to match manifest rows: %w", err)
+		}
+		for _, image := range images {
+			key := image.FileUID
+			if req.MatchBy == models.ImportMatchFileName {
+				key = image.FileName
+			}
+			matches[key] = append(matches[key], image)
+		}
+	}
+
+	resolver := s.taxonomies.Resolver()
+	var pending []labelUpdate
+	for i, row := range req.Rows {
+		result := report.Results[i]
+		if result.Status != "" {
+			continue
+		}
+		images := matches[row.Key]
+		switch {
+		case len(images) == 0:
+			result.Status, result.Error = models.BatchStatusNotFound, fmt.Sprintf("no image with %s %q", req.MatchBy, row.Key)
+			continue
+		case len(images) > 1:
+			ids := make([]string, len(images))
+			for j, image := range images {
+				ids[j] = image.ID
+			}
+			result.Status, result.Error = models.BatchStatusAmbiguous, fmt.Sprintf("%d images match: %s", len(images), strings.Join(ids, ", "))
+			continue
+		}
+
+		image := images[0]
+		result.ImageID = image.ID
+		if !access.Allows(image.DatasetName) {
+			result.Status, result.Error = models.BatchStatusForbidden, fmt.Sprintf("%s permission on dataset %q is required", models.PermissionAnnotate, image.DatasetName)
+			continue
+		}
+		labels, err := resolver.Resolve(ctx, image, image.OrganType, &row.Labels)
+		if errors.Is(err, models.ErrValidation) {
+			result.Status, result.Error = models.BatchStatusInvalid, err.Error()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result.Changes = labels.LabelChanges(image); len(result.Changes) == 0 {
+			result.Status = models.BatchStatusUnchanged
+			continue
+		}
+		if req.DryRun {
+			result.Status = models.BatchStatusWouldUpdate
+			continue
+		}
+		pending = append(pending, labelUpdate{image: image, labels: labels, changes: result.Changes})
+	}
+
+	outcomes := s.writeLabels(ctx, pending)
+	for _, result := range report.Results {
+		if outcome, ok := outcomes[result.ImageID]; ok {
+			result.Status, result.Version, result.Error = outcome.Status, outcome.Version, outcome.Error
+		}
+		switch result.Status {
+		case models.BatchStatusUpdated, models.BatchStatusWouldUpdate:
+			report.Updated++
+		case models.BatchStatusUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+	}
+	return report, nil
+}
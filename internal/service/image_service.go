@@ -3,41 +3,161 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/histopathai/image-catalog-service/config"
 	"github.com/histopathai/image-catalog-service/internal/models"
 	"github.com/histopathai/image-catalog-service/internal/repository"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
 )
 
 // ImageService provides methods to manage images in the catalog.
 type ImageService struct {
-	repo repository.ImageRepository
-	cfg  *config.Config
+	repo        repository.ImageRepository
+	annotations repository.AnnotationRepository
+	audit       *AuditService
+	assets      *AssetDeleter
+	access      *AccessService
+	taxonomies  *TaxonomyService
+	tiles       *tilecache.Cache
+	cfg         *config.Config
 }
 
 // NewImageService creates a new ImageService instance.
-func NewImageService(repo repository.ImageRepository, cfg *config.Config) *ImageService {
+func NewImageService(repo repository.ImageRepository, annotations repository.AnnotationRepository, audit *AuditService, store repository.ObjectStore, access *AccessService, taxonomies *TaxonomyService, tiles *tilecache.Cache, cfg *config.Config) *ImageService {
 	return &ImageService{
-		repo: repo,
-		cfg:  cfg,
+		repo:        repo,
+		annotations: annotations,
+		audit:       audit,
+		assets:      NewAssetDeleter(store, repo, cfg.Storage.DeleteConcurrency, cfg.Storage.DeleteMaxAttempts, cfg.Storage.DeleteBackoff),
+		access:      access,
+		taxonomies:  taxonomies,
+		tiles:       tiles,
+		cfg:         cfg,
 	}
 }
 
+// CreateImage validates and registers a new image record.
+func (s *ImageService) CreateImage(ctx context.Context, req *models.ImageCreateRequest) (*models.Image, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	image := &models.Image{
+		FileName:         req.FileName,
+		FileUID:          req.FileUID,
+		DatasetName:      req.DatasetName,
+		OrganType:        req.OrganType,
+		DiseaseType:      req.DiseaseType,
+		Classification:   req.Classification,
+		SubType:          req.SubType,
+		Grade:            req.Grade,
+		DZIGCSPath:       req.DZIGCSPath,
+		TilesGCSPath:     req.TilesGCSPath,
+		ThumbnailGCSPath: req.ThumbnailGCSPath,
+		Width:            req.Width,
+		Height:           req.Height,
+		Size:             req.Size,
+		Format:           req.Format,
+		TileSize:         req.TileSize,
+		Overlap:          req.Overlap,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		Version:          1,
+	}
+
+	if err := s.repo.Create(ctx, image); err != nil {
+		return nil, fmt.Errorf("failed to create image: %w", err)
+	}
+	return image, nil
+}
+
+// GetImage retrieves an image the caller may read.
 func (s *ImageService) GetImage(ctx context.Context, imageID string) (*models.Image, error) {
+	return s.readAuthorized(ctx, imageID, models.PermissionRead)
+}
+
+// readAuthorized reads an image and checks that the caller holds the
+// required permission on its dataset.
+func (s *ImageService) readAuthorized(ctx context.Context, imageID, required string) (*models.Image, error) {
 	image, err := s.repo.Read(ctx, imageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve image: %w", err)
 	}
+	if err := s.access.Check(ctx, image.DatasetName, required); err != nil {
+		return nil, err
+	}
 	return image, nil
 }
 
-// UpdateImage updates an existing image record.
-func (s *ImageService) UpdateImage(ctx context.Context, imageID string, updateRequest *models.ImageUpdateRequest) (*models.Image, error) {
+// AuthorizeObject returns the image that owns a storage object, provided the
+// caller may read it.
+func (s *ImageService) AuthorizeObject(ctx context.Context, objectName string) (*models.Image, error) {
+	image, err := s.repo.FindByObjectPath(ctx, objectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find image for object: %w", err)
+	}
+	if err := s.access.Check(ctx, image.DatasetName, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// ObjectOwner returns the active image with the ID, provided it owns the
+// storage object, without checking the caller's access. It serves requests
+// that are authorized otherwise, such as signed URLs issued for the image.
+func (s *ImageService) ObjectOwner(ctx context.Context, imageID, objectName string) (*models.Image, error) {
 	image, err := s.repo.Read(ctx, imageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve image: %w", err)
 	}
+	if !image.OwnsObject(objectName) {
+		return nil, models.NewError(models.ErrNotFound, "object %q does not belong to image %q", objectName, imageID)
+	}
+	return image, nil
+}
+
+// UpdateImage updates the dataset, organ type and labels of an image record,
+// provided it is still at the version the caller read; otherwise it fails
+// with models.ErrPrecondition.
+// Labels are checked against the taxonomy of the image's organ type, and
+// every changed diagnostic label is recorded in the audit log.
+func (s *ImageService) UpdateImage(ctx context.Context, imageID string, version int64, updateRequest *models.ImageUpdateRequest) (*models.Image, error) {
+	if err := updateRequest.Validate(); err != nil {
+		return nil, err
+	}
+	image, err := s.readAuthorized(ctx, imageID, models.PermissionAnnotate)
+	if err != nil {
+		return nil, err
+	}
+	if image.Version != version {
+		return nil, models.NewError(models.ErrPrecondition, "image %q has been changed since version %d; fetch it again", imageID, version)
+	}
+	// Moving the image to another dataset needs annotate permission on both.
+	if updateRequest.DatasetName != nil && *updateRequest.DatasetName != image.DatasetName {
+		if err := s.access.Check(ctx, *updateRequest.DatasetName, models.PermissionAnnotate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Moving the image to another organ type checks its labels against
+	// that organ's taxonomy.
+	organType, check := image.OrganType, *updateRequest
+	if updateRequest.OrganType != nil && *updateRequest.OrganType != image.OrganType {
+		organType = *updateRequest.OrganType
+		if check.DiseaseType == nil {
+			check.DiseaseType = image.DiseaseType
+		}
+	}
+	updateRequest, err = s.taxonomies.Resolver().Resolve(ctx, image, organType, &check)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := s.changeEntries(ctx, image, updateRequest.Changes(image), now)
 
 	if updateRequest.DatasetName != nil {
 		image.DatasetName = *updateRequest.DatasetName
@@ -45,43 +165,175 @@ func (s *ImageService) UpdateImage(ctx context.Context, imageID string, updateRe
 	if updateRequest.OrganType != nil {
 		image.OrganType = *updateRequest.OrganType
 	}
-	if updateRequest.DiseaseType != nil {
-		image.DiseaseType = updateRequest.DiseaseType
+	updateRequest.ApplyLabels(image)
+
+	image.UpdatedAt = now
+	err = s.repo.Update(ctx, image, version, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update image: %w", err)
 	}
-	if updateRequest.Classification != nil {
-		image.Classification = updateRequest.Classification
+	return image, nil
+}
+
+// changeEntries returns the audit entries for changes to the fields of an image.
+func (s *ImageService) changeEntries(ctx context.Context, image *models.Image, changes []models.FieldChange, at time.Time) []*models.AuditEntry {
+	entries := make([]*models.AuditEntry, 0, len(changes))
+	for _, change := range changes {
+		entry := s.audit.Entry(ctx, image, models.AuditActionUpdate, at)
+		entry.Field, entry.OldValue, entry.NewValue = change.Field, change.OldValue, change.NewValue
+		entries = append(entries, entry)
 	}
-	if updateRequest.SubType != nil {
-		image.SubType = updateRequest.SubType
+	return entries
+}
+
+// DeleteImage moves an image record to the trash. Its files are kept until
+// the record is purged after the retention period.
+func (s *ImageService) DeleteImage(ctx context.Context, imageID, deletedBy string) error {
+	image, err := s.readAuthorized(ctx, imageID, models.PermissionAdmin)
+	if err != nil {
+		return err
 	}
-	if updateRequest.Grade != nil {
-		image.Grade = updateRequest.Grade
+
+	now := time.Now()
+	entry := s.audit.Entry(ctx, image, models.AuditActionDelete, now)
+	if err := s.repo.SoftDelete(ctx, imageID, deletedBy, now, []*models.AuditEntry{entry}); err != nil {
+		return fmt.Errorf("failed to delete image record: %w", err)
 	}
+	s.invalidateCachedAssets(image)
+	return nil
+}
 
-	image.UpdatedAt = time.Now()
-	err = s.repo.Update(ctx, image)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update image: %w", err)
+// RestoreImage moves an image record from the trash back to the catalog.
+func (s *ImageService) RestoreImage(ctx context.Context, imageID string) (*models.Image, error) {
+	// The repository fills in the dataset of the restored record.
+	entry := s.audit.Entry(ctx, &models.Image{ID: imageID}, models.AuditActionRestore, time.Now())
+	if err := s.repo.Restore(ctx, imageID, []*models.AuditEntry{entry}); err != nil {
+		return nil, fmt.Errorf("failed to restore image: %w", err)
 	}
-	return image, nil
+	return s.GetImage(ctx, imageID)
 }
 
-// DeleteImage deletes an image record and its associated files.
-func (s *ImageService) DeleteImage(ctx context.Context, imageID string) error {
+// ListDeletedImages retrieves a page of images in the trash.
+func (s *ImageService) ListDeletedImages(ctx context.Context, filter *models.ImageFilter) (*models.ImageList, error) {
+	filter.Deleted = true
+	return s.ListImages(ctx, filter)
+}
+
+// PurgeImage permanently deletes a trashed image, its associated files and
+// its annotations.
+// The record is only removed once all of its files are gone, so a failed
+// purge is retried on the next run; partial failures are reported as *AssetDeletionError.
+func (s *ImageService) PurgeImage(ctx context.Context, image *models.Image) error {
+	// Delete the tiles, DZI descriptor and thumbnail
+	if err := s.assets.DeleteImageAssets(ctx, image); err != nil {
+		return fmt.Errorf("failed to delete image assets: %w", err)
+	}
+	s.invalidateCachedAssets(image)
+
+	if err := s.annotations.DeleteByImage(ctx, image.ID); err != nil {
+		return fmt.Errorf("failed to delete image annotations: %w", err)
+	}
 
 	// Delete the image record
-	if err := s.repo.Delete(ctx, imageID); err != nil {
+	entry := s.audit.Entry(ctx, image, models.AuditActionPurge, time.Now())
+	if err := s.repo.Delete(ctx, image.ID, []*models.AuditEntry{entry}); err != nil {
 		return fmt.Errorf("failed to delete image record: %w", err)
 	}
 
 	return nil
 }
 
-// ListImages retrieves all images with optional filtering.
-func (s *ImageService) ListImages(ctx context.Context, filter *models.ImageFilter) ([]*models.Image, error) {
+// InvalidateImageCache drops the cached copies of an image's tiles, DZI
+// descriptor and thumbnail and deletes the thumbnails generated from it, for
+// use after the image has been re-processed. It returns the number of cache
+// entries removed.
+//
+// The cache belongs to this instance. Other instances keep serving cached
+// tiles until the cache TTL, and notice a new DZI descriptor or thumbnail
+// when they revalidate it.
+func (s *ImageService) InvalidateImageCache(ctx context.Context, imageID string) (int, error) {
+	image, err := s.readAuthorized(ctx, imageID, models.PermissionAdmin)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.assets.DeleteDerivedAssets(ctx, image); err != nil {
+		return 0, fmt.Errorf("failed to delete derived image assets: %w", err)
+	}
+	return s.invalidateCachedAssets(image), nil
+}
+
+func (s *ImageService) invalidateCachedAssets(image *models.Image) int {
+	keys := []string{models.ObjectName(image.DZIGCSPath), models.ObjectName(image.ThumbnailGCSPath)}
+	prefixes := []string{image.DerivedPrefix()}
+	if tiles := strings.TrimSuffix(models.ObjectName(image.TilesGCSPath), "/"); tiles != "" {
+		prefixes = append(prefixes, tiles+"/")
+	}
+	return s.tiles.Invalidate(keys, prefixes)
+}
+
+// ListImages retrieves a page of images with optional filtering and sorting.
+func (s *ImageService) ListImages(ctx context.Context, filter *models.ImageFilter) (*models.ImageList, error) {
+	if err := filter.NormalizePagination(); err != nil {
+		return nil, err
+	}
+	if filter.PageToken != "" {
+		if _, err := models.DecodePageCursor(filter.PageToken, filter.SortBy, filter.Order); err != nil {
+			return nil, err
+		}
+	}
+
+	// Restrict the listing to the datasets the caller may read.
+	access, err := s.access.Resolve(ctx, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if !restrictFilter(filter, access) {
+		return &models.ImageList{Images: []*models.Image{}}, nil
+	}
+
 	images, err := s.repo.Filter(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list images: %w", err)
 	}
 	return images, nil
 }
+
+// ExportImages calls fn with every image matching the filter that the caller
+// may read, in the filter's sort order. Images are passed on as they are read
+// from the repository, so exports of any size run in constant memory. The
+// filter's limit and page token are ignored.
+func (s *ImageService) ExportImages(ctx context.Context, filter *models.ImageFilter, fn func(*models.Image) error) error {
+	filter.Limit, filter.PageToken = 0, ""
+	if err := filter.NormalizePagination(); err != nil {
+		return err
+	}
+
+	access, err := s.access.Resolve(ctx, models.PermissionRead)
+	if err != nil {
+		return err
+	}
+	if !restrictFilter(filter, access) {
+		return nil
+	}
+
+	if err := s.repo.Iterate(ctx, filter, fn); err != nil {
+		return fmt.Errorf("failed to export images: %w", err)
+	}
+	return nil
+}
+
+// restrictFilter limits the filter to the datasets in access. It returns
+// false if no dataset is left, in which case nothing can match.
+func restrictFilter(filter *models.ImageFilter, access *DatasetAccess) bool {
+	if access.All {
+		return true
+	}
+	if filter.DatasetName != nil && *filter.DatasetName != "" {
+		return access.Allows(*filter.DatasetName)
+	}
+	if len(access.Datasets) == 0 {
+		return false
+	}
+	filter.DatasetNames = access.Datasets
+	return true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+const purgeBatchSize = 100
+
+// Purger periodically hard-deletes images that have been in the trash for
+// longer than the retention period, together with their files.
+type Purger struct {
+	imageService *ImageService
+	repo         repository.ImageRepository
+	retention    time.Duration
+	interval     time.Duration
+}
+
+func NewPurger(imageService *ImageService, retention, interval time.Duration) *Purger {
+	return &Purger{
+		imageService: imageService,
+		repo:         imageService.repo,
+		retention:    retention,
+		interval:     interval,
+	}
+}
+
+// Start runs the purger in the background until ctx is cancelled.
+func (p *Purger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.PurgeExpired(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeExpired purges every trashed image older than the retention period
+// and returns how many were removed. Images whose files cannot be deleted are
+// left in the trash and retried on the next run.
+func (p *Purger) PurgeExpired(ctx context.Context) int {
+	cutoff := time.Now().Add(-p.retention)
+	purged := 0
+	failed := make(map[string]bool)
+
+	for ctx.Err() == nil {
+		// Images that already failed in this run are still returned, so ask for extra.
+		limit := purgeBatchSize + len(failed)
+		images, err := p.repo.ListDeletedBefore(ctx, cutoff, limit)
+		if err != nil {
+			slog.Error("Failed to list expired images", "error", err)
+			break
+		}
+
+		progress := false
+		for _, image := range images {
+			if failed[image.ID] {
+				continue
+			}
+			if err := p.imageService.PurgeImage(ctx, image); err != nil {
+				slog.Error("Failed to purge image", "image_id", image.ID, "error", err)
+				failed[image.ID] = true
+				continue
+			}
+			purged++
+			progress = true
+		}
+
+		if !progress || len(images) < limit {
+			break
+		}
+	}
+
+	if purged > 0 || len(failed) > 0 {
+		slog.Info("Purged expired images", "purged", purged, "failed", len(failed))
+	}
+	return purged
+}
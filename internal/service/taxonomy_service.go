@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/auth"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// TaxonomyService manages the label taxonomies and checks image labels
+// against them. Images of organ types without a taxonomy accept any label.
+type TaxonomyService struct {
+	repo repository.TaxonomyRepository
+}
+
+// NewTaxonomyService creates a new TaxonomyService instance.
+func NewTaxonomyService(repo repository.TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{
+		repo: repo,
+	}
+}
+
+// ListTaxonomies returns the taxonomies of all organ types.
+func (s *TaxonomyService) ListTaxonomies(ctx context.Context) ([]*models.Taxonomy, error) {
+	taxonomies, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list taxonomies: %w", err)
+	}
+	return taxonomies, nil
+}
+
+// GetTaxonomy returns the taxonomy of an organ type.
+func (s *TaxonomyService) GetTaxonomy(ctx context.Context, organType string) (*models.Taxonomy, error) {
+	taxonomy, err := s.repo.Read(ctx, organType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get taxonomy: %w", err)
+	}
+	return taxonomy, nil
+}
+
+// PutTaxonomy creates or replaces the taxonomy of an organ type. Labels
+// already stored on images are not checked again.
+func (s *TaxonomyService) PutTaxonomy(ctx context.Context, organType string, req *models.TaxonomyRequest) (*models.Taxonomy, error) {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	taxonomy := &models.Taxonomy{
+		OrganType:    organType,
+		Diseases:     req.Diseases,
+		GradeSchemes: req.GradeSchemes,
+		UpdatedBy:    principal.UserID,
+		UpdatedAt:    time.Now(),
+	}
+	if err := taxonomy.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Put(ctx, taxonomy); err != nil {
+		return nil, fmt.Errorf("failed to store taxonomy: %w", err)
+	}
+	return taxonomy, nil
+}
+
+// DeleteTaxonomy removes the taxonomy of an organ type, after which its
+// images accept any label.
+func (s *TaxonomyService) DeleteTaxonomy(ctx context.Context, organType string) error {
+	if err := s.repo.Delete(ctx, organType); err != nil {
+		return fmt.Errorf("failed to delete taxonomy: %w", err)
+	}
+	return nil
+}
+
+// Resolver returns a LabelResolver that reads each taxonomy at most once.
+// It is meant for the duration of one request.
+func (s *TaxonomyService) Resolver() *LabelResolver {
+	return &LabelResolver{repo: s.repo, taxonomies: make(map[string]*models.Taxonomy)}
+}
+
+// LabelResolver checks label updates against the taxonomy of the image's
+// organ type.
+type LabelResolver struct {
+	repo       repository.TaxonomyRepository
+	taxonomies map[string]*models.Taxonomy // nil for organ types without a taxonomy
+}
+
+// Resolve returns the update with its labels replaced by the taxonomy terms
+// they match, or a models.ErrValidation error if a label is not allowed.
+// organType is the organ type the image will have after the update.
+func (r *LabelResolver) Resolve(ctx context.Context, image *models.Image, organType string, update *models.ImageUpdateRequest) (*models.ImageUpdateRequest, error) {
+	if models.ValidateOrganType(organType) != nil {
+		return update, nil // No taxonomy can exist for it
+	}
+	taxonomy, ok := r.taxonomies[organType]
+	if !ok {
+		var err error
+		taxonomy, err = r.repo.Read(ctx, organType)
+		if errors.Is(err, models.ErrNotFound) {
+			taxonomy, err = nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read taxonomy: %w", err)
+		}
+		r.taxonomies[organType] = taxonomy
+	}
+	if taxonomy == nil {
+		return update, nil
+	}
+	return taxonomy.ResolveLabels(image, update)
+}
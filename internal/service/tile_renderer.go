@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/deepzoom"
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"github.com/histopathai/image-catalog-service/internal/tilecache"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/webp" // Decode WebP tiles
+)
+
+// Output formats the renderer can encode.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// TileRenderer reads the Deep Zoom tiles of images and composes them into
+// new images. Callers are responsible for checking that the caller may read
+// the image.
+type TileRenderer struct {
+	store       repository.ObjectStore
+	tiles       *tilecache.Cache
+	load        tilecache.LoadFunc
+	concurrency int
+	jpegQuality int
+}
+
+func NewTileRenderer(store repository.ObjectStore, tiles *tilecache.Cache, cfg config.RenderConfig) *TileRenderer {
+	return &TileRenderer{
+		store:       store,
+		tiles:       tiles,
+		load:        tiles.StoreLoader(store),
+		concurrency: cfg.TileConcurrency,
+		jpegQuality: cfg.JPEGQuality,
+	}
+}
+
+// TileObject returns the object name of a tile of the image.
+func TileObject(img *models.Image, pyramid *deepzoom.Pyramid, level, column, row int) string {
+	return strings.TrimSuffix(models.ObjectName(img.TilesGCSPath), "/") + "/" + pyramid.TileName(level, column, row)
+}
+
+// ReadObject returns a stored object with its body, going through the tile cache.
+func (r *TileRenderer) ReadObject(ctx context.Context, name string) (*tilecache.Entry, error) {
+	entry, err := r.tiles.Get(ctx, name, r.load)
+	if err != nil {
+		return nil, err
+	}
+	return r.readBody(ctx, name, entry)
+}
+
+// readBody fills in the body of an entry that was too large for the cache.
+func (r *TileRenderer) readBody(ctx context.Context, name string, entry *tilecache.Entry) (*tilecache.Entry, error) {
+	if entry.Data != nil {
+		return entry, nil
+	}
+
+	rc, err := r.store.OpenRange(ctx, name, entry.Version, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	uncached := *entry
+	if uncached.Data, err = io.ReadAll(rc); err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return &uncached, nil
+}
+
+// ThumbnailObject returns the object name under which a thumbnail of the
+// image is stored once generated.
+func ThumbnailObject(img *models.Image, size int, format string) string {
+	return fmt.Sprintf("%sthumbnail_%d.%s", img.DerivedPrefix(), size, format)
+}
+
+// Thumbnail returns a thumbnail of the image whose longest side is size
+// pixels, or the full image if it is smaller. Thumbnails are rendered from
+// the lowest pyramid level that covers the size and written back to the
+// store, so each size and format is generated once.
+func (r *TileRenderer) Thumbnail(ctx context.Context, img *models.Image, pyramid *deepzoom.Pyramid, size int, format string) (*tilecache.Entry, error) {
+	name := ThumbnailObject(img, size, format)
+	// A thumbnail deleted on another instance is generated again.
+	entry, err := r.tiles.GetRevalidated(ctx, name, r.store.Stat, func(ctx context.Context, key string) (*tilecache.Entry, error) {
+		entry, err := r.load(ctx, key)
+		if !errors.Is(err, models.ErrNotFound) {
+			return entry, err
+		}
+		return r.generateThumbnail(ctx, img, pyramid, key, size, format)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.readBody(ctx, name, entry)
+}
+
+func (r *TileRenderer) generateThumbnail(ctx context.Context, img *models.Image, pyramid *deepzoom.Pyramid, name string, size int, format string) (*tilecache.Entry, error) {
+	width, height := pyramid.Width, pyramid.Height
+	if width > size || height > size {
+		// Fit the longest side to size, keeping at least one pixel.
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	thumbnail, err := r.Render(ctx, img, pyramid, image.Rect(0, 0, pyramid.Width, pyramid.Height), width, height)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := r.Encode(&buf, thumbnail, format); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	if err := r.store.Write(ctx, name, ContentType(format), bytes.NewReader(buf.Bytes())); err != nil {
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	attrs, err := r.store.Stat(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+	return &tilecache.Entry{
+		Data:         buf.Bytes(),
+		ContentType:  attrs.ContentType,
+		ETag:         attrs.ETag,
+		LastModified: attrs.LastModified,
+		Version:      attrs.Version,
+		Size:         int64(buf.Len()),
+	}, nil
+}
+
+// Render returns a region of the image, given in full resolution pixels,
+// scaled to width by height pixels.
+func (r *TileRenderer) Render(ctx context.Context, img *models.Image, pyramid *deepzoom.Pyramid, region image.Rectangle, width, height int) (*image.RGBA, error) {
+	return pyramid.Render(ctx, region, width, height, r.tileReader(img, pyramid), r.concurrency)
+}
+
+// Compose returns a region of one level of the image at that level's resolution.
+func (r *TileRenderer) Compose(ctx context.Context, img *models.Image, pyramid *deepzoom.Pyramid, level int, region image.Rectangle) (*image.RGBA, error) {
+	return pyramid.Compose(ctx, level, region, r.tileReader(img, pyramid), r.concurrency)
+}
+
+func (r *TileRenderer) tileReader(img *models.Image, pyramid *deepzoom.Pyramid) deepzoom.TileReader {
+	return func(ctx context.Context, level, column, row int) (image.Image, error) {
+		name := TileObject(img, pyramid, level, column, row)
+		entry, err := r.ReadObject(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tile %s: %w", name, err)
+		}
+		tile, _, err := image.Decode(bytes.NewReader(entry.Data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tile %s: %w", name, err)
+		}
+		return tile, nil
+	}
+}
+
+// Encode writes an image in one of the output formats.
+func (r *TileRenderer) Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: r.jpegQuality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return models.NewError(models.ErrValidation, "unsupported output format %q", format)
+	}
+}
+
+// ContentType returns the media type of an output format.
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
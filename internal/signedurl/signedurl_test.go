@@ -0,0 +1,62 @@
+package signedurl
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestSigner(key []byte, now time.Time) *Signer {
+	s := New(key, time.Hour)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	issued := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	signer := newTestSigner(testKey, issued)
+	objectToken := signer.Token("img-1", "slides/img-1/thumb.jpg")
+	prefixToken := signer.Token("img-1", "slides/img-1/tiles/")
+
+	expires, signature, _ := strings.Cut(prefixToken, ".")
+	tamperedSignature := expires + "." + strings.Repeat("A", len(signature))
+	tamperedExpiry := strconv.FormatInt(issued.Add(24*time.Hour).Unix(), 10) + "." + signature
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		imageID string
+		object  string
+		want    error
+	}{
+		{name: "object", signer: signer, token: objectToken, imageID: "img-1", object: "slides/img-1/thumb.jpg"},
+		{name: "object under prefix", signer: signer, token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg"},
+		{name: "just before expiry", signer: newTestSigner(testKey, issued.Add(time.Hour-time.Second)), token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg"},
+		{name: "other object", signer: signer, token: objectToken, imageID: "img-1", object: "slides/img-1/dzi.dzi", want: ErrInvalid},
+		{name: "other image", signer: signer, token: prefixToken, imageID: "img-2", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrInvalid},
+		{name: "sibling prefix", signer: signer, token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles2/0/0_0.jpg", want: ErrInvalid},
+		{name: "prefix itself without slash", signer: signer, token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles", want: ErrInvalid},
+		{name: "tampered signature", signer: signer, token: tamperedSignature, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrInvalid},
+		{name: "tampered expiry", signer: signer, token: tamperedExpiry, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrInvalid},
+		{name: "other key", signer: newTestSigner([]byte("fedcba9876543210fedcba9876543210"), issued), token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrInvalid},
+		{name: "no separator", signer: signer, token: "abc", imageID: "img-1", object: "slides/img-1/thumb.jpg", want: ErrInvalid},
+		{name: "non-numeric expiry", signer: signer, token: "x.y", imageID: "img-1", object: "slides/img-1/thumb.jpg", want: ErrInvalid},
+		{name: "empty", signer: signer, token: "", imageID: "img-1", object: "slides/img-1/thumb.jpg", want: ErrInvalid},
+		{name: "at expiry", signer: newTestSigner(testKey, issued.Add(time.Hour)), token: prefixToken, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrExpired},
+		{name: "expired", signer: newTestSigner(testKey, issued.Add(2*time.Hour)), token: objectToken, imageID: "img-1", object: "slides/img-1/thumb.jpg", want: ErrExpired},
+		{name: "expired and tampered", signer: newTestSigner(testKey, issued.Add(2*time.Hour)), token: tamperedSignature, imageID: "img-1", object: "slides/img-1/tiles/0/0_0.jpg", want: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.token, tt.imageID, tt.object)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
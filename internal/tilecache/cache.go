@@ -0,0 +1,286 @@
+// Package tilecache keeps recently proxied objects close to the service so
+// that repeated tile requests do not each go to object storage.
+package tilecache
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// loadTimeout bounds a shared load, which outlives the request that started it.
+const loadTimeout = time.Minute
+
+// Entry is an object together with the attributes needed to serve it. Data is
+// nil for objects too large to cache.
+type Entry struct {
+	Key          string    `json:"key"`
+	Data         []byte    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	Version      string    `json:"version"`
+	Size         int64     `json:"size"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// LoadFunc reads an object from storage on a cache miss.
+type LoadFunc func(ctx context.Context, key string) (*Entry, error)
+
+// StatFunc reads the current attributes of an object in storage.
+type StatFunc func(ctx context.Context, key string) (*repository.ObjectAttrs, error)
+
+// Tier is one level of the cache, such as memory or local disk. Tiers evict
+// entries on their own to stay within their capacity and must be safe for
+// concurrent use.
+type Tier interface {
+	Get(key string) (*Entry, bool)
+	Set(entry *Entry)
+	Delete(key string) bool
+	DeletePrefix(prefix string) int
+	Stats() TierStats
+}
+
+// TierStats reports the usage of a tier since it was created.
+type TierStats struct {
+	Name      string `json:"name"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	Capacity  int64  `json:"capacity_bytes"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// Stats reports the usage of the whole cache.
+type Stats struct {
+	Hits          uint64      `json:"hits"`
+	Misses        uint64      `json:"misses"`
+	Loads         uint64      `json:"loads"`
+	SharedLoads   uint64      `json:"shared_loads"`  // Misses answered by another caller's load
+	Revalidations uint64      `json:"revalidations"` // Entries checked against storage before they were served
+	Tiers         []TierStats `json:"tiers"`
+}
+
+// Cache looks objects up in its tiers in order, fastest first, and loads
+// them from object storage on a miss. Concurrent misses for the same key
+// share a single load. A cache without tiers only de-duplicates loads.
+type Cache struct {
+	tiers           []Tier
+	ttl             time.Duration
+	revalidateAfter time.Duration
+	maxObjectSize   int64
+	group           singleflight.Group
+
+	// epoch is advanced by every invalidation so that loads which started
+	// before it do not store stale objects.
+	epoch atomic.Uint64
+
+	hits, misses, loads, sharedLoads, revalidations atomic.Uint64
+}
+
+// New creates a cache over the given tiers. Entries older than ttl are
+// reloaded; a ttl of zero keeps them until they are evicted or invalidated.
+// Entries read with GetRevalidated are checked against storage once they are
+// older than revalidateAfter; zero never checks them. Objects larger than
+// maxObjectSize bytes are never cached.
+func New(ttl, revalidateAfter time.Duration, maxObjectSize int64, tiers ...Tier) *Cache {
+	return &Cache{
+		tiers:           tiers,
+		ttl:             ttl,
+		revalidateAfter: revalidateAfter,
+		maxObjectSize:   maxObjectSize,
+	}
+}
+
+// Admits reports whether an object of the given size would be cached, so
+// that loaders only read the body of objects worth keeping.
+func (c *Cache) Admits(size int64) bool {
+	return len(c.tiers) > 0 && size <= c.maxObjectSize
+}
+
+// Get returns the entry for key, calling load on a miss. The load runs
+// detached from ctx because other callers may be waiting for it. Entries
+// whose Data is nil are returned but not stored.
+func (c *Cache) Get(ctx context.Context, key string, load LoadFunc) (*Entry, error) {
+	return c.get(ctx, key, nil, load)
+}
+
+// GetRevalidated is Get for objects that are rewritten in place, such as DZI
+// descriptors and thumbnails. An entry older than the revalidation interval
+// is only served if stat reports the same object version; otherwise it is
+// dropped and loaded again. This way every instance notices a rewritten
+// object within the interval, whether or not it was invalidated there.
+func (c *Cache) GetRevalidated(ctx context.Context, key string, stat StatFunc, load LoadFunc) (*Entry, error) {
+	return c.get(ctx, key, stat, load)
+}
+
+func (c *Cache) get(ctx context.Context, key string, stat StatFunc, load LoadFunc) (*Entry, error) {
+	for i, tier := range c.tiers {
+		entry, ok := tier.Get(key)
+		if !ok {
+			continue
+		}
+		if c.expired(entry) {
+			tier.Delete(key)
+			continue
+		}
+		if stat != nil && c.revalidateAfter > 0 && time.Since(entry.StoredAt) > c.revalidateAfter {
+			if entry, ok = c.revalidate(ctx, entry, stat); !ok {
+				for _, tier := range c.tiers {
+					tier.Delete(key)
+				}
+				break
+			}
+		}
+		// Promote the entry to the faster tiers.
+		for _, faster := range c.tiers[:i] {
+			faster.Set(entry)
+		}
+		c.hits.Add(1)
+		return entry, nil
+	}
+	c.misses.Add(1)
+
+	loaded := false
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		loaded = true
+		c.loads.Add(1)
+		epoch := c.epoch.Load()
+
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		entry, err := load(loadCtx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Key = key
+		entry.StoredAt = time.Now()
+		if entry.Data != nil && c.Admits(int64(len(entry.Data))) && c.epoch.Load() == epoch {
+			for _, tier := range c.tiers {
+				tier.Set(entry)
+			}
+		}
+		return entry, nil
+	})
+	if !loaded {
+		c.sharedLoads.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Entry), nil
+}
+
+// revalidate checks an entry against the current attributes of its object.
+// If the object is unchanged, it returns the entry restamped as just stored,
+// which the tiers keep for another interval.
+func (c *Cache) revalidate(ctx context.Context, entry *Entry, stat StatFunc) (*Entry, bool) {
+	c.revalidations.Add(1)
+	attrs, err := stat(ctx, entry.Key)
+	if err != nil || !sameObject(entry, attrs) {
+		return nil, false
+	}
+	checked := *entry
+	checked.StoredAt = time.Now()
+	for _, tier := range c.tiers {
+		tier.Set(&checked)
+	}
+	return &checked, true
+}
+
+// sameObject reports whether the attributes describe the object the entry
+// was loaded from, by its version if the store has versions and otherwise by
+// its ETag.
+func sameObject(entry *Entry, attrs *repository.ObjectAttrs) bool {
+	if entry.Version != "" || attrs.Version != "" {
+		return entry.Version == attrs.Version
+	}
+	return entry.ETag != "" && entry.ETag == attrs.ETag
+}
+
+// Invalidate removes the entries for the given keys and for all keys under
+// the given prefixes, returning the number of entries removed from each tier
+// combined.
+func (c *Cache) Invalidate(keys []string, prefixes []string) int {
+	c.epoch.Add(1)
+
+	removed := 0
+	for _, tier := range c.tiers {
+		for _, key := range keys {
+			if tier.Delete(key) {
+				removed++
+			}
+		}
+		for _, prefix := range prefixes {
+			removed += tier.DeletePrefix(prefix)
+		}
+	}
+	return removed
+}
+
+// StoreLoader returns a LoadFunc that reads objects from the store. It reads
+// the body only of objects the cache admits; larger objects are returned
+// with their attributes alone and must be read by the caller.
+func (c *Cache) StoreLoader(store repository.ObjectStore) LoadFunc {
+	return func(ctx context.Context, key string) (*Entry, error) {
+		attrs, err := store.Stat(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &Entry{
+			ContentType:  attrs.ContentType,
+			ETag:         attrs.ETag,
+			LastModified: attrs.LastModified,
+			Version:      attrs.Version,
+			Size:         attrs.Size,
+		}
+		if !c.Admits(attrs.Size) {
+			return entry, nil
+		}
+
+		rc, err := store.OpenRange(ctx, key, attrs.Version, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		if entry.Data, err = io.ReadAll(rc); err != nil {
+			return nil, err
+		}
+		// Ranges are served from the bytes actually read.
+		entry.Size = int64(len(entry.Data))
+		return entry, nil
+	}
+}
+
+// Stats reports hit, miss and load counters and the usage of each tier.
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Loads:         c.loads.Load(),
+		SharedLoads:   c.sharedLoads.Load(),
+		Revalidations: c.revalidations.Load(),
+		Tiers:         make([]TierStats, 0, len(c.tiers)),
+	}
+	for _, tier := range c.tiers {
+		stats.Tiers = append(stats.Tiers, tier.Stats())
+	}
+	return stats
+}
+
+func (c *Cache) expired(entry *Entry) bool {
+	return c.ttl > 0 && time.Since(entry.StoredAt) > c.ttl
+}
+
+// hasPrefix reports whether key is under prefix; an empty prefix matches nothing.
+func hasPrefix(key, prefix string) bool {
+	return prefix != "" && strings.HasPrefix(key, prefix)
+}
@@ -0,0 +1,200 @@
+package tilecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/histopathai/image-catalog-service/internal/models"
+	"github.com/histopathai/image-catalog-service/internal/repository"
+)
+
+// staticLoader returns a LoadFunc serving data at version, counting its calls.
+func staticLoader(data, version string, calls *atomic.Int32) LoadFunc {
+	return func(ctx context.Context, key string) (*Entry, error) {
+		calls.Add(1)
+		return &Entry{Data: []byte(data), Version: version, ETag: `"` + version + `"`, Size: int64(len(data))}, nil
+	}
+}
+
+func TestGetSharesConcurrentLoads(t *testing.T) {
+	c := New(0, 0, 1<<20, NewMemoryTier(1<<20))
+	const callers = 8
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context, key string) (*Entry, error) {
+		calls.Add(1)
+		<-release
+		return &Entry{Data: []byte("tile")}, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := c.Get(context.Background(), "tiles/0/0_0.jpeg", load)
+			if err == nil && string(entry.Data) != "tile" {
+				err = errors.New("wrong data " + string(entry.Data))
+			}
+			errs <- err
+		}()
+	}
+	// Release the load once every caller has missed.
+	for deadline := time.Now().Add(5 * time.Second); c.Stats().Misses < callers; {
+		if time.Now().After(deadline) {
+			t.Fatal("callers did not miss")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	stats := c.Stats()
+	if calls.Load() != 1 || stats.Loads != 1 || stats.SharedLoads != callers-1 {
+		t.Errorf("load calls = %d, stats = %+v, want one load shared by %d callers", calls.Load(), stats, callers-1)
+	}
+	if _, err := c.Get(context.Background(), "tiles/0/0_0.jpeg", load); err != nil || calls.Load() != 1 {
+		t.Errorf("Get() after the load: err = %v, load calls = %d, want a hit", err, calls.Load())
+	}
+}
+
+func TestInvalidateDuringLoad(t *testing.T) {
+	c := New(0, 0, 1<<20, NewMemoryTier(1<<20))
+	key := "tiles/img/0/0_0.jpeg"
+
+	// The object is re-processed and invalidated while an old copy loads.
+	load := func(ctx context.Context, key string) (*Entry, error) {
+		c.Invalidate(nil, []string{"tiles/img/"})
+		return &Entry{Data: []byte("old")}, nil
+	}
+	if entry, err := c.Get(context.Background(), key, load); err != nil || string(entry.Data) != "old" {
+		t.Fatalf("Get() = %v, %v", entry, err)
+	}
+
+	var calls atomic.Int32
+	entry, err := c.Get(context.Background(), key, staticLoader("new", "2", &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 || string(entry.Data) != "new" {
+		t.Errorf("Get() = %q with %d loads, want the new object loaded again", entry.Data, calls.Load())
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	c := New(0, 0, 1<<20, NewMemoryTier(1<<20))
+	var calls atomic.Int32
+	for _, key := range []string{"a.dzi", "a/0/0_0.jpeg", "a/1/0_0.jpeg", "ab/0/0_0.jpeg"} {
+		if _, err := c.Get(context.Background(), key, staticLoader("x", "1", &calls)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if removed := c.Invalidate([]string{"a.dzi", "missing"}, []string{"a/", ""}); removed != 3 {
+		t.Errorf("Invalidate() = %d, want 3", removed)
+	}
+	if entries := c.Stats().Tiers[0].Entries; entries != 1 {
+		t.Errorf("entries left = %d, want 1", entries)
+	}
+}
+
+func TestGetRevalidated(t *testing.T) {
+	tests := []struct {
+		name            string
+		revalidateAfter time.Duration
+		age             time.Duration
+		stat            *repository.ObjectAttrs
+		statErr         error
+		wantStat        bool
+		wantData        string
+	}{
+		{name: "fresh", revalidateAfter: time.Minute, age: time.Second, wantData: "old"},
+		{name: "unchanged", revalidateAfter: time.Minute, age: time.Hour, stat: &repository.ObjectAttrs{Version: "1"}, wantStat: true, wantData: "old"},
+		{name: "rewritten", revalidateAfter: time.Minute, age: time.Hour, stat: &repository.ObjectAttrs{Version: "2"}, wantStat: true, wantData: "new"},
+		{name: "deleted", revalidateAfter: time.Minute, age: time.Hour, statErr: models.NewError(models.ErrNotFound, "gone"), wantStat: true, wantData: "new"},
+		{name: "never revalidated", age: time.Hour, wantData: "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tier := NewMemoryTier(1 << 20)
+			c := New(0, tt.revalidateAfter, 1<<20, tier)
+			key := "images/a.dzi"
+			tier.Set(&Entry{Key: key, Data: []byte("old"), Version: "1", StoredAt: time.Now().Add(-tt.age)})
+
+			statCalls := 0
+			stat := func(ctx context.Context, name string) (*repository.ObjectAttrs, error) {
+				statCalls++
+				return tt.stat, tt.statErr
+			}
+			var loads atomic.Int32
+			entry, err := c.GetRevalidated(context.Background(), key, stat, staticLoader("new", "2", &loads))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(entry.Data) != tt.wantData {
+				t.Errorf("data = %q, want %q", entry.Data, tt.wantData)
+			}
+			if (statCalls > 0) != tt.wantStat {
+				t.Errorf("stat calls = %d, want a call: %v", statCalls, tt.wantStat)
+			}
+
+			// A checked or reloaded entry is not checked again until it is due.
+			statCalls = 0
+			if _, err := c.GetRevalidated(context.Background(), key, stat, staticLoader("new", "2", &loads)); err != nil {
+				t.Fatal(err)
+			}
+			if tt.revalidateAfter > 0 && statCalls != 0 {
+				t.Errorf("stat calls on the second Get = %d, want 0", statCalls)
+			}
+		})
+	}
+}
+
+func TestGetDoesNotRevalidate(t *testing.T) {
+	tier := NewMemoryTier(1 << 20)
+	c := New(0, time.Minute, 1<<20, tier)
+	tier.Set(&Entry{Key: "tiles/0/0_0.jpeg", Data: []byte("old"), Version: "1", StoredAt: time.Now().Add(-time.Hour)})
+
+	var loads atomic.Int32
+	entry, err := c.Get(context.Background(), "tiles/0/0_0.jpeg", staticLoader("new", "2", &loads))
+	if err != nil || string(entry.Data) != "old" || c.Stats().Revalidations != 0 {
+		t.Errorf("Get() = %q, %v with %d revalidations, want the cached entry unchecked", entry.Data, err, c.Stats().Revalidations)
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	tier := NewMemoryTier(1 << 20)
+	c := New(time.Hour, 0, 1<<20, tier)
+	tier.Set(&Entry{Key: "k", Data: []byte("old"), StoredAt: time.Now().Add(-2 * time.Hour)})
+
+	var loads atomic.Int32
+	entry, err := c.Get(context.Background(), "k", staticLoader("new", "2", &loads))
+	if err != nil || string(entry.Data) != "new" || loads.Load() != 1 {
+		t.Errorf("Get() = %q, %v with %d loads, want the expired entry reloaded", entry.Data, err, loads.Load())
+	}
+}
+
+func TestGetTooLarge(t *testing.T) {
+	c := New(0, 0, 4, NewMemoryTier(1<<20))
+	var loads atomic.Int32
+	for range 2 {
+		if _, err := c.Get(context.Background(), "big", staticLoader("12345", "1", &loads)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads.Load() != 2 {
+		t.Errorf("loads = %d, want every Get of an object over the size limit to load it", loads.Load())
+	}
+}
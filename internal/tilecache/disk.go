@@ -0,0 +1,273 @@
+package tilecache
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const diskTempPrefix = ".tmp-"
+
+// DiskTier is a least-recently-used cache of files in a local directory,
+// bounded by their total size. Each file holds the entry's attributes as a
+// JSON line followed by the object's bytes. The index is rebuilt from the
+// directory on start, so the cache survives restarts.
+type DiskTier struct {
+	mu       sync.Mutex
+	dir      string
+	capacity int64
+	size     int64
+	order    *list.List // Of *diskRecord, most recently used first
+	records  map[string]*list.Element
+
+	hits, misses, evictions uint64
+}
+
+type diskRecord struct {
+	key  string
+	path string
+	size int64
+}
+
+// NewDiskTier creates a disk tier holding up to capacity bytes in dir,
+// indexing the entries a previous run left there.
+func NewDiskTier(dir string, capacity int64) (*DiskTier, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	d := &DiskTier{
+		dir:      dir,
+		capacity: capacity,
+		order:    list.New(),
+		records:  make(map[string]*list.Element),
+	}
+	if err := d.load(); err != nil {
+		return nil, fmt.Errorf("failed to index cache directory: %w", err)
+	}
+	return d, nil
+}
+
+func (d *DiskTier) Get(key string) (*Entry, bool) {
+	d.mu.Lock()
+	element, ok := d.records[key]
+	if !ok {
+		d.misses++
+		d.mu.Unlock()
+		return nil, false
+	}
+	d.order.MoveToFront(element)
+	path := element.Value.(*diskRecord).path
+	d.mu.Unlock()
+
+	entry, err := readEntry(path, true)
+	if err != nil || entry.Key != key {
+		slog.Warn("Dropping unreadable tile cache file", "path", path, "error", err)
+		d.Delete(key)
+		d.mu.Lock()
+		d.misses++
+		d.mu.Unlock()
+		return nil, false
+	}
+
+	d.mu.Lock()
+	d.hits++
+	d.mu.Unlock()
+	return entry, true
+}
+
+func (d *DiskTier) Set(entry *Entry) {
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	size := int64(len(header) + 1 + len(entry.Data))
+	if size > d.capacity {
+		return
+	}
+
+	// Write to a temporary file first so readers never see a partial entry.
+	tmp, err := os.CreateTemp(d.dir, diskTempPrefix)
+	if err != nil {
+		slog.Warn("Failed to write tile cache file", "error", err)
+		return
+	}
+	_, err = tmp.Write(append(append(header, '\n'), entry.Data...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	path := d.path(entry.Key)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		slog.Warn("Failed to write tile cache file", "error", err)
+		os.Remove(tmp.Name())
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		slog.Warn("Failed to write tile cache file", "error", err)
+		os.Remove(tmp.Name())
+		return
+	}
+	if element, ok := d.records[entry.Key]; ok {
+		d.order.Remove(element)
+		d.size -= element.Value.(*diskRecord).size
+	}
+	d.records[entry.Key] = d.order.PushFront(&diskRecord{key: entry.Key, path: path, size: size})
+	d.size += size
+	d.evict()
+}
+
+func (d *DiskTier) Delete(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.remove(key)
+}
+
+func (d *DiskTier) DeletePrefix(prefix string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	removed := 0
+	for key := range d.records {
+		if hasPrefix(key, prefix) && d.remove(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+func (d *DiskTier) Stats() TierStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return TierStats{
+		Name:      "disk",
+		Entries:   len(d.records),
+		Bytes:     d.size,
+		Capacity:  d.capacity,
+		Hits:      d.hits,
+		Misses:    d.misses,
+		Evictions: d.evictions,
+	}
+}
+
+// path returns the file for a key. Keys are hashed because object names may
+// be longer than a file name or contain characters the file system rejects.
+func (d *DiskTier) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// remove deletes an entry and its file; the caller must hold d.mu.
+func (d *DiskTier) remove(key string) bool {
+	element, ok := d.records[key]
+	if !ok {
+		return false
+	}
+	record := element.Value.(*diskRecord)
+	d.order.Remove(element)
+	delete(d.records, key)
+	d.size -= record.size
+	if err := os.Remove(record.path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove tile cache file", "path", record.path, "error", err)
+	}
+	return true
+}
+
+// evict removes the least recently used entries until the tier fits its
+// capacity; the caller must hold d.mu.
+func (d *DiskTier) evict() {
+	for d.size > d.capacity {
+		oldest := d.order.Back()
+		d.remove(oldest.Value.(*diskRecord).key)
+		d.evictions++
+	}
+}
+
+// load indexes the files in the directory, oldest last, and removes
+// temporary and unreadable files.
+func (d *DiskTier) load() error {
+	type found struct {
+		record  *diskRecord
+		modTime int64
+	}
+	var files []found
+
+	err := filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), diskTempPrefix) {
+			return os.Remove(path)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		cached, err := readEntry(path, false)
+		if err != nil || d.path(cached.Key) != path {
+			slog.Warn("Removing unreadable tile cache file", "path", path, "error", err)
+			return os.Remove(path)
+		}
+		files = append(files, found{
+			record:  &diskRecord{key: cached.Key, path: path, size: info.Size()},
+			modTime: info.ModTime().UnixNano(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime > files[j].modTime })
+	for _, file := range files {
+		d.records[file.record.key] = d.order.PushBack(file.record)
+		d.size += file.record.size
+	}
+	d.evict()
+	return nil
+}
+
+// readEntry parses a cache file, reading the object's bytes only if withData is set.
+func readEntry(path string, withData bool) (*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(bytes.TrimSuffix(header, []byte("\n")), &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+	if withData {
+		if entry.Data, err = io.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+	return &entry, nil
+}
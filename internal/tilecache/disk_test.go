@@ -0,0 +1,135 @@
+package tilecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func diskEntry(key, data string) *Entry {
+	return &Entry{Key: key, Data: []byte(data), ContentType: "image/jpeg", ETag: `"1"`, Version: "1", Size: int64(len(data)), StoredAt: time.Now()}
+}
+
+func TestDiskTierGetSet(t *testing.T) {
+	d, err := NewDiskTier(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Get("a"); ok {
+		t.Fatal("Get() of a missing key succeeded")
+	}
+	d.Set(diskEntry("a", "first"))
+	d.Set(diskEntry("a", "second"))
+
+	entry, ok := d.Get("a")
+	if !ok || string(entry.Data) != "second" || entry.ContentType != "image/jpeg" || entry.Key != "a" {
+		t.Fatalf("Get() = %+v, %v", entry, ok)
+	}
+	if stats := d.Stats(); stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestDiskTierEvictsLeastRecentlyUsed(t *testing.T) {
+	d, err := NewDiskTier(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Size the tier to hold two of the entries.
+	d.Set(diskEntry("a", "aaaa"))
+	d.capacity = 2 * d.Stats().Bytes
+	d.Set(diskEntry("b", "bbbb"))
+	d.Get("a")
+	d.Set(diskEntry("c", "cccc"))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := d.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+	if stats := d.Stats(); stats.Evictions != 1 || stats.Bytes > stats.Capacity {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestDiskTierReload(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewDiskTier(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Set(diskEntry("tiles/img/0/0_0.jpeg", "tile"))
+	d.Set(diskEntry("img.dzi", "dzi"))
+
+	// Temporary files of interrupted writes and foreign files are removed.
+	tmp := filepath.Join(dir, diskTempPrefix+"1")
+	foreign := filepath.Join(dir, "ab", "not-an-entry")
+	for _, path := range []string{tmp, foreign} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("junk"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewDiskTier(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := reopened.Stats(); stats.Entries != 2 || stats.Bytes != d.Stats().Bytes {
+		t.Errorf("reopened stats = %+v, want the 2 entries of %d bytes", stats, d.Stats().Bytes)
+	}
+	if entry, ok := reopened.Get("tiles/img/0/0_0.jpeg"); !ok || string(entry.Data) != "tile" {
+		t.Errorf("Get() after reopening = %+v, %v", entry, ok)
+	}
+	for _, path := range []string{tmp, foreign} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+}
+
+func TestDiskTierDelete(t *testing.T) {
+	d, err := NewDiskTier(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"img.dzi", "tiles/img/0/0_0.jpeg", "tiles/img/1/0_0.jpeg", "tiles/img2/0/0_0.jpeg"} {
+		d.Set(diskEntry(key, key))
+	}
+
+	if !d.Delete("img.dzi") || d.Delete("img.dzi") {
+		t.Error("Delete() did not report removing the entry once")
+	}
+	if removed := d.DeletePrefix("tiles/img/"); removed != 2 {
+		t.Errorf("DeletePrefix() = %d, want 2", removed)
+	}
+	if removed := d.DeletePrefix(""); removed != 0 {
+		t.Errorf("DeletePrefix(\"\") = %d, want 0", removed)
+	}
+	if _, ok := d.Get("tiles/img2/0/0_0.jpeg"); !ok {
+		t.Error("entry outside the prefix was removed")
+	}
+	if stats := d.Stats(); stats.Entries != 1 {
+		t.Errorf("entries = %d, want 1", stats.Entries)
+	}
+}
+
+func TestDiskTierDropsUnreadableFiles(t *testing.T) {
+	d, err := NewDiskTier(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Set(diskEntry("a", "data"))
+	if err := os.WriteFile(d.path("a"), []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Get("a"); ok {
+		t.Error("Get() of a corrupted file succeeded")
+	}
+	if stats := d.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("stats = %+v, want the entry dropped", stats)
+	}
+}
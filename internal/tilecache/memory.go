@@ -0,0 +1,111 @@
+package tilecache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryTier is a least-recently-used cache bounded by the total size of the
+// objects it holds.
+type MemoryTier struct {
+	mu       sync.Mutex
+	capacity int64
+	size     int64
+	order    *list.List // Of *Entry, most recently used first
+	entries  map[string]*list.Element
+
+	hits, misses, evictions uint64
+}
+
+// NewMemoryTier creates a memory tier holding up to capacity bytes.
+func NewMemoryTier(capacity int64) *MemoryTier {
+	return &MemoryTier{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryTier) Get(key string) (*Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		m.misses++
+		return nil, false
+	}
+	m.hits++
+	m.order.MoveToFront(element)
+	return element.Value.(*Entry), true
+}
+
+func (m *MemoryTier) Set(entry *Entry) {
+	cost := entryCost(entry)
+	if cost > m.capacity {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(entry.Key)
+	m.entries[entry.Key] = m.order.PushFront(entry)
+	m.size += cost
+
+	for m.size > m.capacity {
+		oldest := m.order.Back()
+		m.remove(oldest.Value.(*Entry).Key)
+		m.evictions++
+	}
+}
+
+func (m *MemoryTier) Delete(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(key)
+}
+
+func (m *MemoryTier) DeletePrefix(prefix string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for key := range m.entries {
+		if hasPrefix(key, prefix) && m.remove(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+func (m *MemoryTier) Stats() TierStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return TierStats{
+		Name:      "memory",
+		Entries:   len(m.entries),
+		Bytes:     m.size,
+		Capacity:  m.capacity,
+		Hits:      m.hits,
+		Misses:    m.misses,
+		Evictions: m.evictions,
+	}
+}
+
+// remove drops an entry; the caller must hold m.mu.
+func (m *MemoryTier) remove(key string) bool {
+	element, ok := m.entries[key]
+	if !ok {
+		return false
+	}
+	m.order.Remove(element)
+	delete(m.entries, key)
+	m.size -= entryCost(element.Value.(*Entry))
+	return true
+}
+
+// entryCost approximates the memory an entry occupies.
+func entryCost(entry *Entry) int64 {
+	return int64(len(entry.Data) + len(entry.Key) + len(entry.ContentType) + len(entry.ETag))
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/histopathai/image-catalog-service/config"
+	"github.com/histopathai/image-catalog-service/internal/auth"
 	"github.com/histopathai/image-catalog-service/internal/handlers"
 	"github.com/histopathai/image-catalog-service/internal/routes"
 )
@@ -21,13 +22,13 @@ type Server struct {
 	config     *config.Config
 }
 
-func NewServer(cfg *config.Config, imageHandler *handlers.ImageHandler, gcsProxyHandler *handlers.GCSProxyHandler) *Server {
+func NewServer(cfg *config.Config, imageHandler *handlers.ImageHandler, aclHandler *handlers.ACLHandler, annotationHandler *handlers.AnnotationHandler, auditHandler *handlers.AuditHandler, taxonomyHandler *handlers.TaxonomyHandler, exportHandler *handlers.ExportHandler, gcsProxyHandler *handlers.GCSProxyHandler, iiifHandler *handlers.IIIFHandler, renderHandler *handlers.RenderHandler, authenticator auth.Authenticator) *Server {
 
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := routes.SetupRouter(imageHandler, gcsProxyHandler, cfg)
+	router := routes.SetupRouter(imageHandler, aclHandler, annotationHandler, auditHandler, taxonomyHandler, exportHandler, gcsProxyHandler, iiifHandler, renderHandler, authenticator, cfg)
 
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),